@@ -5,19 +5,28 @@ package checker
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"reflect"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/spacemonkeygo/monkit/v3"
 	"github.com/zeebo/errs"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 
+	"storj.io/common/sync2"
 	"storj.io/common/version"
 )
 
@@ -26,12 +35,48 @@ var (
 
 	// Error is the error class for version checker client errors.
 	Error = errs.Class("version checker client")
+
+	// ErrUnknownProcess is returned when the process name is not known by the client.
+	ErrUnknownProcess = errors.New("invalid process name")
+
+	// ErrServerStatus is returned when the version control server responds with a non-success
+	// status code. The status code is available with ServerStatusError.
+	ErrServerStatus = errors.New("non-success http status code")
+
+	// ErrDecode is returned when the version control server response can't be decoded.
+	ErrDecode = errors.New("invalid version server response")
+
+	// ErrCachedResponse is returned together with the response cached on disk, when the version control
+	// server can't be reached. The cached response may be outdated, up to CacheMaxAge.
+	ErrCachedResponse = errors.New("version server is unreachable, using the cached response")
 )
 
+// ServerStatusError is the error of a non-success response of the version control server.
+// It matches ErrServerStatus with errors.Is.
+type ServerStatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error implements error.
+func (e *ServerStatusError) Error() string {
+	return fmt.Sprintf("%v: %d; body: %s\n", ErrServerStatus, e.StatusCode, e.Body)
+}
+
+// Is makes errors.Is(err, ErrServerStatus) true.
+func (e *ServerStatusError) Is(target error) bool {
+	return target == ErrServerStatus
+}
+
 // ClientConfig is the config struct for the version control client.
 type ClientConfig struct {
-	ServerAddress  string        `help:"server address to check its version against" default:"https://version.storj.io"`
-	RequestTimeout time.Duration `help:"Request timeout for version checks" default:"0h1m0s"`
+	ServerAddress      string        `help:"comma separated server addresses to check its version against (http(s):// or unix:///path/to/socket), tried in order until one of them responds" default:"https://version.storj.io"`
+	RequestTimeout     time.Duration `help:"Request timeout for version checks" default:"0h1m0s"`
+	MaxRetries         int           `help:"maximum number of retries of failed version checks (connection errors and 5xx responses)" default:"0"`
+	RetryBackoff       time.Duration `help:"initial delay between version check retries, doubled after each retry" default:"1s"`
+	FetchSingleProcess bool          `help:"request only the checked process from the version server (with the process query parameter)" default:"false"`
+	CachePath          string        `help:"path of the file to cache the version server response in, used when the version server is unreachable (disabled when empty)" default:""`
+	CacheMaxAge        time.Duration `help:"maximum age of the cached version server response which can be used" default:"24h0m0s"`
 }
 
 // Client defines helper methods for using version control server response data.
@@ -39,75 +84,468 @@ type ClientConfig struct {
 // architecture: Client
 type Client struct {
 	config ClientConfig
+	// servers are the version control servers in the order of ServerAddress, the first one
+	// which responds is used.
+	servers []server
+
+	// mu guards the response cache of the last successful All request.
+	mu sync.Mutex
+	// etagServer is the index of the server, which returned etag.
+	etagServer int
+	etag       string
+	cached     version.AllowedVersions
+}
+
+// server is a version control server of the client.
+type server struct {
+	// address is the HTTP address of the version control server, which is requested with httpClient.
+	address    string
+	httpClient *http.Client
 }
 
 // New constructs a new verson control server client.
 func New(config ClientConfig) *Client {
-	return &Client{
-		config: config,
+	return NewWithTransport(config, nil)
+}
+
+// NewWithTransport constructs a new version control server client, which sends the requests with
+// the given transport (eg. to use a proxy or custom TLS settings). The default transport is used when
+// transport is nil, or a transport dialing the socket for the unix:// server addresses.
+func NewWithTransport(config ClientConfig, transport http.RoundTripper) *Client {
+	client := &Client{config: config}
+	for _, address := range strings.Split(config.ServerAddress, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+
+		serverTransport := transport
+		if socketPath, ok := strings.CutPrefix(address, unixSocketPrefix); ok {
+			if serverTransport == nil {
+				serverTransport = unixSocketTransport(socketPath)
+			}
+			// the host is ignored by the transport, but the request needs an HTTP address.
+			address = "http://unix/"
+		}
+
+		client.servers = append(client.servers, server{
+			address: address,
+			// Tune Client to have a custom Timeout (reduces hanging software)
+			httpClient: &http.Client{
+				Transport: serverTransport,
+				Timeout:   config.RequestTimeout,
+			},
+		})
 	}
+	return client
 }
 
 // All handles the HTTP request to gather the latest version information.
+// Connection errors and 5xx responses are retried up to MaxRetries times. Responses without the
+// processes field, or with a process without a minimum version, are rejected with ErrDecode.
+// With multiple server addresses, the servers are tried in order until one of them returns a
+// valid response, and the errors of all the servers are returned when none of them does.
+// With CachePath, the successful responses are cached on disk, and when the request fails,
+// the cached response (not older than CacheMaxAge) is returned with an error matching ErrCachedResponse.
 func (client *Client) All(ctx context.Context) (ver version.AllowedVersions, err error) {
 	defer mon.Task()(&ctx)(&err)
 
-	// Tune Client to have a custom Timeout (reduces hanging software)
-	httpClient := http.Client{
-		Timeout: client.config.RequestTimeout,
+	err = client.eachServer(ctx, func(index int, server server) (err error) {
+		ver, err = client.allFromServer(ctx, index, server)
+		return err
+	})
+	if err != nil {
+		if cached, ok := client.readCache(); ok {
+			return cached, fmt.Errorf("%w: %w", ErrCachedResponse, err)
+		}
+		return version.AllowedVersions{}, err
 	}
+	return ver, nil
+}
 
-	// New Request that used the passed in context
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.config.ServerAddress, nil)
+// eachServer calls fn with the servers in order, until it succeeds with one of them.
+// The errors of all the servers are returned when it doesn't succeed with any of them.
+func (client *Client) eachServer(ctx context.Context, fn func(index int, server server) error) error {
+	if len(client.servers) == 0 {
+		return Error.New("no version server address")
+	}
+
+	var group errs.Group
+	for index, server := range client.servers {
+		err := fn(index, server)
+		if err == nil {
+			return nil
+		}
+		group.Add(err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return group.Err()
+}
+
+// allFromServer requests all the processes from the given server.
+func (client *Client) allFromServer(ctx context.Context, index int, server server) (ver version.AllowedVersions, err error) {
+	client.mu.Lock()
+	var etag string
+	if client.etagServer == index {
+		etag = client.etag
+	}
+	client.mu.Unlock()
+
+	resp, err := client.fetchWithRetry(ctx, server, server.address, etag)
 	if err != nil {
-		return version.AllowedVersions{}, Error.Wrap(err)
+		return version.AllowedVersions{}, err
+	}
+
+	if resp.notModified {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.cached, nil
 	}
 
-	resp, err := httpClient.Do(req)
+	err = json.NewDecoder(bytes.NewReader(resp.body)).Decode(&ver)
 	if err != nil {
+		return version.AllowedVersions{}, Error.Wrap(decodeError(err))
+	}
+	if err := validateAllowedVersions(resp.body, ver); err != nil {
 		return version.AllowedVersions{}, Error.Wrap(err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.etag != "" {
+		client.mu.Lock()
+		client.etagServer = index
+		client.etag = resp.etag
+		client.cached = ver
+		client.mu.Unlock()
+	}
+	client.writeCache(resp.body)
+	return ver, nil
+}
+
+// validateAllowedVersions checks that the decoded response has the processes field, and that
+// every process in it has a minimum version. Otherwise the response would be silently handled as
+// if the server didn't restrict the versions.
+func validateAllowedVersions(body []byte, ver version.AllowedVersions) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return decodeError(err)
+	}
+	if raw, ok := fields["processes"]; !ok || string(raw) == "null" {
+		return fmt.Errorf("%w: missing processes field", ErrDecode)
+	}
+
+	var invalid []string
+	for _, entry := range processes {
+		process := entry.get(ver.Processes)
+		if process != (version.Process{}) && process.Minimum.Version == "" {
+			invalid = append(invalid, entry.name)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("%w: missing minimum version of processes: %s", ErrDecode, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// readCache returns the response cached on disk, if it's not older than CacheMaxAge.
+func (client *Client) readCache() (ver version.AllowedVersions, ok bool) {
+	if client.config.CachePath == "" {
+		return version.AllowedVersions{}, false
+	}
+
+	info, err := os.Stat(client.config.CachePath)
+	if err != nil || time.Since(info.ModTime()) > client.config.CacheMaxAge {
+		return version.AllowedVersions{}, false
+	}
+
+	data, err := os.ReadFile(client.config.CachePath)
 	if err != nil {
-		return version.AllowedVersions{}, Error.Wrap(err)
+		return version.AllowedVersions{}, false
+	}
+	if err := json.Unmarshal(data, &ver); err != nil {
+		return version.AllowedVersions{}, false
+	}
+	return ver, true
+}
+
+// writeCache replaces the response cached on disk. Failures are only reported as a metric,
+// as the cache is not required for the version checks.
+func (client *Client) writeCache(body []byte) {
+	if client.config.CachePath == "" {
+		return
+	}
+
+	err := func() (err error) {
+		file, err := os.CreateTemp(filepath.Dir(client.config.CachePath), filepath.Base(client.config.CachePath)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				err = errs.Combine(err, os.Remove(file.Name()))
+			}
+		}()
+
+		_, err = file.Write(body)
+		err = errs.Combine(err, file.Close())
+		if err != nil {
+			return err
+		}
+		return os.Rename(file.Name(), client.config.CachePath)
+	}()
+	if err != nil {
+		mon.Event("version_cache_write_failed")
+	}
+}
+
+// unixSocketPrefix is the prefix of the server addresses of version control servers listening on a unix socket.
+const unixSocketPrefix = "unix://"
+
+// unixSocketTransport creates a transport, which connects to the unix socket regardless of the requested address.
+func unixSocketTransport(socketPath string) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+	return transport
+}
+
+// response is a successful response of the version control server.
+type response struct {
+	body        []byte
+	etag        string
+	notModified bool
+}
+
+// fetchWithRetry requests the address from the server, retrying connection errors and 5xx responses
+// up to MaxRetries times.
+func (client *Client) fetchWithRetry(ctx context.Context, server server, address, etag string) (resp response, err error) {
+	backoff := client.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		resp, retryable, err = client.fetch(ctx, server, address, etag)
+		if err == nil || !retryable || attempt >= client.config.MaxRetries {
+			return resp, err
+		}
+
+		if !sync2.Sleep(ctx, backoff) {
+			return response{}, Error.Wrap(errs.Combine(err, ctx.Err()))
+		}
+		backoff *= 2
+	}
+}
+
+// fetch does a single request to the version control server. retryable is set when the
+// request failed with a connection error or a server error.
+func (client *Client) fetch(ctx context.Context, server server, address, etag string) (_ response, retryable bool, err error) {
+	// New Request that used the passed in context
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return response{}, false, Error.Wrap(err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	// setting Accept-Encoding disables the transparent decompression of the transport.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := server.httpClient.Do(req)
+	if err != nil {
+		return response{}, ctx.Err() == nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, Error.Wrap(resp.Body.Close())) }()
+
+	var reader io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, gzipErr := gzip.NewReader(resp.Body)
+		if gzipErr != nil {
+			return response{}, false, Error.Wrap(decodeError(gzipErr))
+		}
+		defer func() { err = errs.Combine(err, Error.Wrap(gzipReader.Close())) }()
+		reader = gzipReader
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return response{}, false, Error.Wrap(err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return response{notModified: true}, false, nil
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return version.AllowedVersions{}, Error.New("non-success http status code: %d; body: %s\n", resp.StatusCode, body)
+		return response{}, resp.StatusCode >= 500, Error.Wrap(&ServerStatusError{StatusCode: resp.StatusCode, Body: body})
 	}
 
-	err = json.NewDecoder(bytes.NewReader(body)).Decode(&ver)
-	return ver, Error.Wrap(err)
+	if contentType := resp.Header.Get("Content-Type"); !strings.Contains(contentType, "application/json") {
+		return response{}, false, Error.Wrap(fmt.Errorf("%w: unexpected content type %q (status code: %d); body: %s", ErrDecode, contentType, resp.StatusCode, bodySnippet(body)))
+	}
+
+	return response{body: body, etag: resp.Header.Get("ETag")}, false, nil
+}
+
+// decodeError marks err as an ErrDecode error.
+func decodeError(err error) error {
+	return fmt.Errorf("%w: %w", ErrDecode, err)
+}
+
+// maxBodySnippet is the maximum number of bytes of an unexpected response body included in errors.
+const maxBodySnippet = 256
+
+// bodySnippet returns the beginning of the response body for error messages.
+func bodySnippet(body []byte) string {
+	if len(body) > maxBodySnippet {
+		return string(body[:maxBodySnippet]) + "..."
+	}
+	return string(body)
 }
 
 // Process returns the version info for the named process from the version control server response.
+// With FetchSingleProcess only the named process is requested from the server.
 func (client *Client) Process(ctx context.Context, processName string) (process version.Process, err error) {
 	defer mon.Task()(&ctx, processName)(&err)
 
+	entry, ok := processes[kebabToPascal(processName)]
+	if !ok {
+		return version.Process{}, Error.Wrap(fmt.Errorf("%w: %s\n", ErrUnknownProcess, processName))
+	}
+
+	if client.config.FetchSingleProcess {
+		return client.singleProcess(ctx, entry)
+	}
+
 	versions, err := client.All(ctx)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrCachedResponse) {
 		return version.Process{}, Error.Wrap(err)
 	}
 
-	processesValue := reflect.ValueOf(versions.Processes)
-	field := processesValue.FieldByName(kebabToPascal(processName))
+	return entry.get(versions.Processes), err
+}
+
+// Processes returns the names of the processes, which have version info in the version control
+// server response, in alphabetical order. The names can be used with Process.
+func (client *Client) Processes(ctx context.Context) (names []string, err error) {
+	defer mon.Task()(&ctx)(&err)
 
-	processNameErr := Error.New("invalid process name: %s\n", processName)
-	if field == (reflect.Value{}) {
-		return version.Process{}, processNameErr
+	versions, err := client.All(ctx)
+	if err != nil && !errors.Is(err, ErrCachedResponse) {
+		return nil, err
 	}
 
-	process, ok := field.Interface().(version.Process)
-	if !ok {
-		return version.Process{}, processNameErr
+	for _, entry := range processes {
+		if entry.get(versions.Processes) != (version.Process{}) {
+			names = append(names, entry.name)
+		}
 	}
+	sort.Strings(names)
+	return names, err
+}
 
+// IsAllowed returns whether the running version of the named process is at least the minimum
+// version of the version control server, and the suggested version to upgrade to.
+func (client *Client) IsAllowed(ctx context.Context, processName string, running version.SemVer) (allowed bool, suggested version.SemVer, err error) {
+	defer mon.Task()(&ctx, processName)(&err)
+
+	process, fetchErr := client.Process(ctx, processName)
+	if fetchErr != nil && !errors.Is(fetchErr, ErrCachedResponse) {
+		return false, version.SemVer{}, fetchErr
+	}
+
+	minimum, err := process.Minimum.SemVer()
+	if err != nil {
+		return false, version.SemVer{}, Error.Wrap(decodeError(err))
+	}
+
+	suggested, err = process.Suggested.SemVer()
+	if err != nil {
+		return false, version.SemVer{}, Error.Wrap(decodeError(err))
+	}
+
+	return running.Compare(minimum) >= 0, suggested, fetchErr
+}
+
+// singleProcess requests only the given process from the version control servers. Servers which
+// don't support the process query parameter return all the processes, which is also accepted.
+func (client *Client) singleProcess(ctx context.Context, entry processEntry) (process version.Process, err error) {
+	err = client.eachServer(ctx, func(_ int, server server) (err error) {
+		process, err = client.singleProcessFromServer(ctx, server, entry)
+		return err
+	})
+	if err != nil {
+		if cached, ok := client.readCache(); ok {
+			return entry.get(cached.Processes), fmt.Errorf("%w: %w", ErrCachedResponse, err)
+		}
+		return version.Process{}, err
+	}
 	return process, nil
 }
 
-// kebabToPascal converts `alpha-beta` to `AlphaBeta`.
+// singleProcessFromServer requests only the given process from the given server.
+func (client *Client) singleProcessFromServer(ctx context.Context, server server, entry processEntry) (process version.Process, err error) {
+	address, err := url.Parse(server.address)
+	if err != nil {
+		return version.Process{}, Error.Wrap(err)
+	}
+	query := address.Query()
+	query.Set("process", entry.name)
+	address.RawQuery = query.Encode()
+
+	resp, err := client.fetchWithRetry(ctx, server, address.String(), "")
+	if err != nil {
+		return version.Process{}, err
+	}
+
+	var all struct {
+		Processes *version.Processes `json:"processes"`
+	}
+	if err := json.Unmarshal(resp.body, &all); err != nil {
+		return version.Process{}, Error.Wrap(decodeError(err))
+	}
+	if all.Processes != nil {
+		return entry.get(*all.Processes), nil
+	}
+
+	if err := json.Unmarshal(resp.body, &process); err != nil {
+		return version.Process{}, Error.Wrap(decodeError(err))
+	}
+	return process, nil
+}
+
+// processEntry describes a process of the version control server response.
+type processEntry struct {
+	// name is the name of the process in the version control server response.
+	name string
+	get  func(version.Processes) version.Process
+}
+
+// processes is the registry of the processes in the version control server response,
+// keyed by the field name of the process in version.Processes. New processes should be registered here.
+var processes = map[string]processEntry{
+	"Satellite":          {name: "satellite", get: func(p version.Processes) version.Process { return p.Satellite }},
+	"Storagenode":        {name: "storagenode", get: func(p version.Processes) version.Process { return p.Storagenode }},
+	"StoragenodeUpdater": {name: "storagenode-updater", get: func(p version.Processes) version.Process { return p.StoragenodeUpdater }},
+	"Uplink":             {name: "uplink", get: func(p version.Processes) version.Process { return p.Uplink }},
+	"Gateway":            {name: "gateway", get: func(p version.Processes) version.Process { return p.Gateway }},
+	"Identity":           {name: "identity", get: func(p version.Processes) version.Process { return p.Identity }},
+}
+
+// kebabToPascal converts `alpha-beta` to `AlphaBeta`. Empty segments (consecutive, leading or
+// trailing dashes) are ignored, and the rest of the segments are kept as is (`gateway-mt-v2` is `GatewayMtV2`).
 func kebabToPascal(str string) string {
-	return strings.ReplaceAll(cases.Title(language.Und, cases.NoLower).String(str), "-", "")
+	var pascal strings.Builder
+	for _, segment := range strings.Split(str, "-") {
+		if segment == "" {
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(segment)
+		pascal.WriteRune(unicode.ToUpper(first))
+		pascal.WriteString(segment[size:])
+	}
+	return pascal.String()
 }
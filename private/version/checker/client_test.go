@@ -4,10 +4,20 @@
 package checker_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
@@ -51,6 +61,452 @@ func TestClient_All(t *testing.T) {
 	}
 }
 
+func TestClient_All_UnexpectedContentType(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><body>Please log in to use the network.</body></html>"))
+	}))
+	defer server.Close()
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: server.URL,
+	})
+
+	_, err := client.All(ctx)
+	require.Error(t, err)
+	require.True(t, checker.Error.Has(err))
+	require.Contains(t, err.Error(), "text/html")
+	require.Contains(t, err.Error(), "status code: 200")
+	require.Contains(t, err.Error(), "Please log in")
+}
+
+func TestClient_All_NotModified(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	expected := version.AllowedVersions{}
+	expected.Processes.Storagenode.Minimum.Version = "v1.2.3"
+	expected.Processes.Storagenode.Suggested.Version = "v1.2.4"
+	payload, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	var requests, notModified atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: server.URL,
+	})
+
+	for i := 0; i < 3; i++ {
+		versions, err := client.All(ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, versions)
+	}
+	require.EqualValues(t, 3, requests.Load())
+	require.EqualValues(t, 2, notModified.Load())
+}
+
+func TestClient_All_Retry(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	payload, err := json.Marshal(version.AllowedVersions{})
+	require.NoError(t, err)
+
+	var requests atomic.Int64
+	newServer := func(failures int64, status int) *httptest.Server {
+		requests.Store(0)
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(payload)
+		}))
+	}
+
+	t.Run("server error", func(t *testing.T) {
+		server := newServer(2, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress: server.URL,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+		})
+		_, err := client.All(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, requests.Load())
+	})
+
+	t.Run("too many server errors", func(t *testing.T) {
+		server := newServer(3, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress: server.URL,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+		})
+		_, err := client.All(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, 3, requests.Load())
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		server := newServer(1, http.StatusNotFound)
+		defer server.Close()
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress: server.URL,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+		})
+		_, err := client.All(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		server := newServer(1, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress: server.URL,
+		})
+		_, err := client.All(ctx)
+		require.Error(t, err)
+		require.EqualValues(t, 1, requests.Load())
+	})
+}
+
+func TestClient_All_Gzip(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	expected := version.AllowedVersions{}
+	expected.Processes.Satellite.Minimum.Version = "v1.2.3"
+	payload, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	_, err = gzipWriter.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write(payload)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: server.URL,
+	})
+
+	versions, err := client.All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	expected := version.AllowedVersions{}
+	expected.Processes.Storagenode.Minimum.Version = "v1.2.3"
+	payload, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	socketPath := ctx.File("version.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	require.NoError(t, server.Listener.Close())
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: "unix://" + socketPath,
+	})
+
+	versions, err := client.All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+
+	process, err := client.Process(ctx, "storagenode")
+	require.NoError(t, err)
+	require.Equal(t, expected.Processes.Storagenode, process)
+
+	_, err = checker.New(checker.ClientConfig{
+		ServerAddress: "unix://" + ctx.File("missing.sock"),
+	}).All(ctx)
+	require.Error(t, err)
+}
+
+func TestClient_Failover(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	expected := version.AllowedVersions{}
+	expected.Processes.Storagenode.Minimum.Version = "v1.2.3"
+	payload, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	newServer := func(requests *atomic.Int64, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(payload)
+		}))
+	}
+
+	var primaryRequests, backupRequests, failingRequests atomic.Int64
+	primary := newServer(&primaryRequests, http.StatusOK)
+	defer primary.Close()
+	backup := newServer(&backupRequests, http.StatusOK)
+	defer backup.Close()
+	failing := newServer(&failingRequests, http.StatusNotFound)
+	defer failing.Close()
+
+	versions, err := checker.New(checker.ClientConfig{
+		ServerAddress: primary.URL + "," + backup.URL,
+	}).All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+	require.EqualValues(t, 1, primaryRequests.Load())
+	require.EqualValues(t, 0, backupRequests.Load())
+
+	versions, err = checker.New(checker.ClientConfig{
+		ServerAddress: failing.URL + ", " + backup.URL,
+	}).All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+	require.EqualValues(t, 1, failingRequests.Load())
+	require.EqualValues(t, 1, backupRequests.Load())
+
+	process, err := checker.New(checker.ClientConfig{
+		ServerAddress:      failing.URL + "," + backup.URL,
+		FetchSingleProcess: true,
+	}).Process(ctx, "storagenode")
+	require.NoError(t, err)
+	require.Equal(t, expected.Processes.Storagenode, process)
+
+	_, err = checker.New(checker.ClientConfig{
+		ServerAddress: failing.URL + "," + failing.URL + "/missing",
+	}).All(ctx)
+	require.ErrorIs(t, err, checker.ErrServerStatus)
+	require.EqualValues(t, 4, failingRequests.Load())
+
+	_, err = checker.New(checker.ClientConfig{}).All(ctx)
+	require.Error(t, err)
+}
+
+func TestClient_Cache(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	expected := version.AllowedVersions{}
+	expected.Processes.Storagenode.Minimum.Version = "v1.2.3"
+	expected.Processes.Storagenode.Suggested.Version = "v1.2.4"
+	payload, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	cachePath := ctx.File("version.json")
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: server.URL,
+		CachePath:     cachePath,
+		CacheMaxAge:   time.Hour,
+	})
+
+	versions, err := client.All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, versions)
+	require.FileExists(t, cachePath)
+
+	unavailable.Store(true)
+
+	versions, err = client.All(ctx)
+	require.ErrorIs(t, err, checker.ErrCachedResponse)
+	require.ErrorIs(t, err, checker.ErrServerStatus)
+	require.Equal(t, expected, versions)
+
+	process, err := client.Process(ctx, "storagenode")
+	require.ErrorIs(t, err, checker.ErrCachedResponse)
+	require.Equal(t, expected.Processes.Storagenode, process)
+
+	running, err := version.NewSemVer("v1.2.3")
+	require.NoError(t, err)
+
+	allowed, suggested, err := client.IsAllowed(ctx, "storagenode", running)
+	require.ErrorIs(t, err, checker.ErrCachedResponse)
+	require.True(t, allowed)
+	require.Equal(t, "v1.2.4", suggested.String())
+
+	t.Run("stale", func(t *testing.T) {
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(cachePath, old, old))
+
+		_, err := client.All(ctx)
+		require.ErrorIs(t, err, checker.ErrServerStatus)
+		require.NotErrorIs(t, err, checker.ErrCachedResponse)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := checker.New(checker.ClientConfig{
+			ServerAddress: server.URL,
+			CachePath:     ctx.File("missing.json"),
+			CacheMaxAge:   time.Hour,
+		}).All(ctx)
+		require.ErrorIs(t, err, checker.ErrServerStatus)
+		require.NotErrorIs(t, err, checker.ErrCachedResponse)
+	})
+}
+
+func TestClient_Process_FetchSingleProcess(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	t.Run("supported", func(t *testing.T) {
+		expected := version.Process{}
+		expected.Minimum.Version = "v1.2.3"
+		expected.Suggested.Version = "v1.2.4"
+		payload, err := json.Marshal(expected)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("process") != "storagenode-updater" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(payload)
+		}))
+		defer server.Close()
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress:      server.URL,
+			FetchSingleProcess: true,
+		})
+
+		process, err := client.Process(ctx, "storagenode-updater")
+		require.NoError(t, err)
+		require.Equal(t, expected, process)
+
+		process, err = client.Process(ctx, "StoragenodeUpdater")
+		require.NoError(t, err)
+		require.Equal(t, expected, process)
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		peer := newTestPeer(t, ctx)
+		defer ctx.Check(peer.Close)
+
+		client := checker.New(checker.ClientConfig{
+			ServerAddress:      "http://" + peer.Addr(),
+			FetchSingleProcess: true,
+		})
+
+		process, err := client.Process(ctx, "storagenode")
+		require.NoError(t, err)
+
+		expected, err := checker.New(checker.ClientConfig{
+			ServerAddress: "http://" + peer.Addr(),
+		}).Process(ctx, "storagenode")
+		require.NoError(t, err)
+		require.Equal(t, expected, process)
+	})
+}
+
+func TestClient_Errors(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/invalid":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{"))
+		case "/no-processes":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"versions":{}}`))
+		case "/no-minimum":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"processes":{"storagenode":{"suggested":{"version":"v1.2.4"}}}}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer server.Close()
+
+	_, err := checker.New(checker.ClientConfig{ServerAddress: server.URL}).Process(ctx, "unknown")
+	require.ErrorIs(t, err, checker.ErrUnknownProcess)
+	require.True(t, checker.Error.Has(err))
+
+	_, err = checker.New(checker.ClientConfig{ServerAddress: server.URL + "/missing"}).All(ctx)
+	require.ErrorIs(t, err, checker.ErrServerStatus)
+	require.True(t, checker.Error.Has(err))
+	var statusErr *checker.ServerStatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+
+	_, err = checker.New(checker.ClientConfig{ServerAddress: server.URL + "/invalid"}).All(ctx)
+	require.ErrorIs(t, err, checker.ErrDecode)
+	require.True(t, checker.Error.Has(err))
+
+	_, err = checker.New(checker.ClientConfig{ServerAddress: server.URL + "/no-processes"}).All(ctx)
+	require.ErrorIs(t, err, checker.ErrDecode)
+	require.ErrorContains(t, err, "missing processes field")
+
+	_, err = checker.New(checker.ClientConfig{ServerAddress: server.URL + "/no-minimum"}).Process(ctx, "storagenode")
+	require.ErrorIs(t, err, checker.ErrDecode)
+	require.ErrorContains(t, err, "missing minimum version of processes: storagenode")
+}
+
 func TestClient_Process(t *testing.T) {
 	ctx := testcontext.New(t)
 	defer ctx.Cleanup()
@@ -86,6 +542,88 @@ func TestClient_Process(t *testing.T) {
 	}
 }
 
+type countingTransport struct {
+	requests atomic.Int64
+}
+
+func (transport *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_CustomTransport(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	peer := newTestPeer(t, ctx)
+	defer ctx.Check(peer.Close)
+
+	transport := &countingTransport{}
+	client := checker.NewWithTransport(checker.ClientConfig{
+		ServerAddress: "http://" + peer.Addr(),
+	}, transport)
+
+	_, err := client.All(ctx)
+	require.NoError(t, err)
+	_, err = client.Process(ctx, "storagenode")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, transport.requests.Load())
+}
+
+func TestClient_Processes(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	peer := newTestPeer(t, ctx)
+	defer ctx.Check(peer.Close)
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: "http://" + peer.Addr(),
+	})
+
+	names, err := client.Processes(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gateway", "identity", "satellite", "storagenode", "storagenode-updater", "uplink"}, names)
+
+	for _, name := range names {
+		_, err := client.Process(ctx, name)
+		require.NoError(t, err)
+	}
+}
+
+func TestClient_IsAllowed(t *testing.T) {
+	ctx := testcontext.New(t)
+	defer ctx.Cleanup()
+
+	peer := newTestPeer(t, ctx)
+	defer ctx.Check(peer.Close)
+
+	client := checker.New(checker.ClientConfig{
+		ServerAddress: "http://" + peer.Addr(),
+	})
+
+	// the minimum and suggested version of the satellite is v1.2.3.
+	for _, tt := range []struct {
+		running string
+		allowed bool
+	}{
+		{"v1.2.2", false},
+		{"v1.2.3", true},
+		{"v1.3.0", true},
+	} {
+		running, err := version.NewSemVer(tt.running)
+		require.NoError(t, err)
+
+		allowed, suggested, err := client.IsAllowed(ctx, "satellite", running)
+		require.NoError(t, err)
+		require.Equal(t, tt.allowed, allowed, tt.running)
+		require.Equal(t, "v1.2.3", suggested.String())
+	}
+
+	_, _, err := client.IsAllowed(ctx, "unknown", version.SemVer{})
+	require.ErrorIs(t, err, checker.ErrUnknownProcess)
+}
+
 func newTestPeer(t *testing.T, ctx *testcontext.Context) *versioncontrol.Peer {
 	t.Helper()
 
@@ -5,6 +5,7 @@ package checker
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -102,7 +103,10 @@ func (service *Service) checkVersion(ctx context.Context) (_ version.SemVer, all
 	}()
 
 	process, err := service.client.Process(ctx, service.service)
-	if err != nil {
+	switch {
+	case errors.Is(err, ErrCachedResponse):
+		service.log.Warn("using cached process version info", zap.Error(err))
+	case err != nil:
 		service.log.Error("failed to get process version info", zap.Error(err))
 		return service.acceptedVersion, true
 	}
@@ -4,9 +4,12 @@
 package checker
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"storj.io/common/version"
 )
 
 func TestKebabToPascal(t *testing.T) {
@@ -17,4 +20,32 @@ func TestKebabToPascal(t *testing.T) {
 	require.Equal(t, "Uplink", kebabToPascal("uplink"))
 	require.Equal(t, "Gateway", kebabToPascal("gateway"))
 	require.Equal(t, "Identity", kebabToPascal("identity"))
+
+	for _, tt := range []struct {
+		kebab  string
+		pascal string
+	}{
+		{"gateway-mt-v2", "GatewayMtV2"},
+		{"gateway-mt-2", "GatewayMt2"},
+		{"gateway-2fa", "Gateway2fa"},
+		{"storagenode--updater", "StoragenodeUpdater"},
+		{"storagenode-updater-", "StoragenodeUpdater"},
+		{"-storagenode-updater", "StoragenodeUpdater"},
+		{"storagenode-Updater", "StoragenodeUpdater"},
+		{"-", ""},
+		{"", ""},
+	} {
+		require.Equal(t, tt.pascal, kebabToPascal(tt.kebab), tt.kebab)
+	}
+}
+
+func TestProcessesRegistry(t *testing.T) {
+	processesType := reflect.TypeOf(version.Processes{})
+	require.Len(t, processes, processesType.NumField())
+	for i := 0; i < processesType.NumField(); i++ {
+		field := processesType.Field(i)
+		entry, ok := processes[field.Name]
+		require.True(t, ok, "%s is not registered", field.Name)
+		require.Equal(t, field.Tag.Get("json"), entry.name)
+	}
 }
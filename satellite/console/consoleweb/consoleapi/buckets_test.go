@@ -101,6 +101,7 @@ func TestBucketMetadata(t *testing.T) {
 					plcStr += fmt.Sprintf(`%d:annotation("location", "%s"); `, k, v)
 				}
 				config.Placement = nodeselection.ConfigurablePlacementRule{PlacementRules: plcStr}
+				config.PlacementAllowEveryCountryOverride = true
 			},
 		},
 	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
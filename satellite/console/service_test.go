@@ -61,6 +61,7 @@ func TestService(t *testing.T) {
 					plcStr += fmt.Sprintf(`%d:annotation("location", "%s"); `, k, v)
 				}
 				config.Placement = nodeselection.ConfigurablePlacementRule{PlacementRules: plcStr}
+				config.PlacementAllowEveryCountryOverride = true
 				config.Console.VarPartners = []string{"partner1"}
 			},
 		},
@@ -140,6 +140,7 @@ type Core struct {
 		StorjscanClient  *storjscan.Client
 		StorjscanService *storjscan.Service
 		StorjscanChore   *storjscan.Chore
+		UnappliedStat    *stripe.UnappliedStat
 	}
 
 	ConsoleDBCleanup struct {
@@ -252,7 +253,7 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB,
 		}
 	}
 
-	placement, err := config.Placement.Parse(config.Overlay.Node.CreateDefaultPlacement)
+	placement, err := config.PlacementRules().Parse(config.Overlay.Node.CreateDefaultPlacement)
 	if err != nil {
 		return nil, err
 	}
@@ -554,6 +555,21 @@ func New(log *zap.Logger, full *identity.FullIdentity, db DB,
 			Run:   peer.Payments.BillingChore.Run,
 			Close: peer.Payments.BillingChore.Close,
 		})
+
+		peer.Payments.UnappliedStat = stripe.NewUnappliedStat(
+			peer.Log.Named("payments.stripe:unapplied-stat"),
+			monkit.Default,
+			peer.DB.StripeCoinPayments().Transactions(),
+			pc.StripeCoinPayments.UnappliedStatInterval,
+		)
+		peer.Services.Add(lifecycle.Item{
+			Name:  "payments.stripe:unapplied-stat",
+			Run:   peer.Payments.UnappliedStat.Run,
+			Close: peer.Payments.UnappliedStat.Close,
+		})
+		peer.Debug.Server.Panel.Add(
+			debug.Cycle("Payments Unapplied Stat", peer.Payments.UnappliedStat.Loop),
+		)
 	}
 
 	{ // setup account freeze
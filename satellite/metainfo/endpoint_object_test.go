@@ -2734,6 +2734,7 @@ func TestNodeTagPlacement(t *testing.T) {
 					config.Metainfo.RS.Total = 6
 					config.Metainfo.MaxInlineSegmentSize = 1
 					config.Placement = placementRules
+					config.PlacementAllowEveryCountryOverride = true
 				},
 				StorageNode: func(index int, config *storagenode.Config) {
 					if index%2 == 0 {
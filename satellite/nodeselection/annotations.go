@@ -15,4 +15,8 @@ const (
 	// Location is the placement annotation key for meaningful and
 	// human-readable descriptions of placements.
 	Location = "location"
+
+	// AnnotationLocationName is the placement annotation key of the
+	// human-readable name of the placement. Same as Location.
+	AnnotationLocationName = Location
 )
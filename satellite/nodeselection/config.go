@@ -77,25 +77,35 @@ func LoadConfig(configFile string) (PlacementDefinitions, error) {
 		}
 
 		invariant := resolveTemplates(def.Invariant)
-		p.Invariant, err = InvariantFromString(invariant)
+		var invariantExcludesSubnet bool
+		p.Invariant, invariantExcludesSubnet, err = invariantFromString(invariant)
 		if err != nil {
 			return placements, errs.New("Invariant definition '%s' of placement %d is invalid: %v", invariant, def.ID, err)
 		}
 
 		selector := resolveTemplates(def.Selector)
-		p.Selector, err = SelectorFromString(selector)
+		var selectorExcludesSubnet bool
+		p.Selector, selectorExcludesSubnet, err = selectorFromString(selector)
 		if err != nil {
 			return placements, errs.New("Selector definition '%s' of placement %d is invalid: %v", selector, def.ID, err)
 		}
+		p.ExcludeSameSubnet = invariantExcludesSubnet || selectorExcludesSubnet
 
 		placements[def.ID] = p
 	}
 	return placements, nil
 }
 
+// countryFilter implements country() of the placement DSL. It's a named function, as spread(country, n)
+// recognizes it when it's used as an attribute.
+func countryFilter(countries ...string) (NodeFilter, error) {
+	return NewCountryFilterFromString(countries)
+}
+
 var supportedFilters = map[any]any{
-	"country": func(countries ...string) (NodeFilter, error) {
-		return NewCountryFilterFromString(countries)
+	"country": countryFilter,
+	"continent": func(continent string) (NodeFilter, error) {
+		return NewContinentFilter(continent)
 	},
 	"all": func(filters ...NodeFilter) (NodeFilters, error) {
 		res := NodeFilters{}
@@ -121,41 +131,74 @@ var supportedFilters = map[any]any{
 		}
 		return OrFilter{filter1, filter2}, nil
 	},
+	// not is a built-in operator of mito (like !), not a function.
+	mito.ModNot: notFilterOp,
 	"tag": func(nodeIDstr string, key string, value any) (NodeFilters, error) {
 		nodeID, err := storj.NodeIDFromString(nodeIDstr)
 		if err != nil {
 			return nil, err
 		}
 
-		var rawValue []byte
-		match := bytes.Equal
-		switch v := value.(type) {
-		case string:
-			rawValue = []byte(v)
-		case []byte:
-			rawValue = v
-		case stringNotMatch:
-			match = func(a, b []byte) bool {
-				return !bytes.Equal(a, b)
-			}
-			rawValue = []byte(v)
-		default:
-			return nil, ErrPlacement.New("3rd argument of tag() should be string or []byte")
+		rawValue, match, valueExpr, err := tagValueMatch(value)
+		if err != nil {
+			return nil, err
 		}
+		filter := NewTagFilter(nodeID, key, rawValue, match)
+		filter.valueExpr = valueExpr
 		res := NodeFilters{
-			NewTagFilter(nodeID, key, rawValue, match),
+			filter,
 		}
 		return res, nil
 	},
+	"anytag": func(key string, value any) (NodeFilters, error) {
+		rawValue, match, valueExpr, err := tagValueMatch(value)
+		if err != nil {
+			return nil, err
+		}
+		filter := NewAnySignerTagFilter(key, rawValue, match)
+		filter.valueExpr = valueExpr
+		return NodeFilters{
+			filter,
+		}, nil
+	},
+	"tagprefix": func(nodeIDstr string, key string, prefix string) (NodeFilters, error) {
+		return tagAffixFilter("tagprefix", nodeIDstr, key, prefix, bytes.HasPrefix)
+	},
+	"tagsuffix": func(nodeIDstr string, key string, suffix string) (NodeFilters, error) {
+		return tagAffixFilter("tagsuffix", nodeIDstr, key, suffix, bytes.HasSuffix)
+	},
 	"exclude": func(filter NodeFilter) (NodeFilter, error) {
 		return NewExcludeFilter(filter), nil
 	},
+	"atLeast": func(threshold int64, filters ...NodeFilter) (NodeFilter, error) {
+		return NewThresholdFilter(int(threshold), filters...)
+	},
+	"version": func(constraint string) (NodeFilter, error) {
+		return NewVersionFilter(constraint)
+	},
+	"lastContact": func(maxAge string) (NodeFilter, error) {
+		return NewLastContactFilter(maxAge)
+	},
+	"minFreeDisk": func(size string) (NodeFilter, error) {
+		return NewFreeDiskFilter(size)
+	},
+	"spread": spreadFilter,
+	"vetted": func(vetted bool) (NodeFilter, error) {
+		return NewVettedFilter(vetted), nil
+	},
+	// boolean values, like vetted(false).
+	"true":  true,
+	"false": false,
 	"empty": func() string {
 		return ""
 	},
 	"notEmpty": func() any {
 		return stringNotMatch("")
 	},
+	"gt":       tagGreaterThan,
+	"gte":      tagGreaterOrEqual,
+	"lt":       tagLessThan,
+	"lte":      tagLessOrEqual,
 	"nodelist": AllowedNodesFromFile,
 	"select":   NewAttributeFilter,
 }
@@ -174,6 +217,13 @@ func FilterFromString(expr string) (NodeFilter, error) {
 
 // SelectorFromString parses complex node selection rules from config lines.
 func SelectorFromString(expr string) (NodeSelectorInit, error) {
+	selector, _, err := selectorFromString(expr)
+	return selector, err
+}
+
+// selectorFromString parses the node selection rules, and also returns whether the selector selects
+// at most one node from a subnet (it groups the nodes by last_net, even if it's wrapped by other selectors).
+func selectorFromString(expr string) (_ NodeSelectorInit, excludesSubnet bool, err error) {
 	if expr == "" {
 		expr = "random()"
 	}
@@ -183,6 +233,9 @@ func SelectorFromString(expr string) (NodeSelectorInit, error) {
 			if err != nil {
 				return nil, err
 			}
+			if attribute == "last_net" {
+				excludesSubnet = true
+			}
 			return AttributeGroupSelector(attr), nil
 		},
 		"random": func() (NodeSelectorInit, error) {
@@ -206,15 +259,22 @@ func SelectorFromString(expr string) (NodeSelectorInit, error) {
 	}
 	selector, err := mito.Eval(expr, env)
 	if err != nil {
-		return nil, errs.New("Invalid selector definition '%s', %v", expr, err)
+		return nil, false, errs.New("Invalid selector definition '%s', %v", expr, err)
 	}
-	return selector.(NodeSelectorInit), nil
+	return selector.(NodeSelectorInit), excludesSubnet, nil
 }
 
 // InvariantFromString parses complex invariants (~declumping rules) from config lines.
 func InvariantFromString(expr string) (Invariant, error) {
+	invariant, _, err := invariantFromString(expr)
+	return invariant, err
+}
+
+// invariantFromString parses the invariant, and also returns whether the invariant allows at most one
+// node from a subnet (maxcontrol("last_net",1)).
+func invariantFromString(expr string) (_ Invariant, excludesSubnet bool, err error) {
 	if expr == "" {
-		return AllGood(), nil
+		return AllGood(), false, nil
 	}
 	env := map[any]any{
 		"maxcontrol": func(attribute string, max int64) (Invariant, error) {
@@ -222,12 +282,15 @@ func InvariantFromString(expr string) (Invariant, error) {
 			if err != nil {
 				return nil, err
 			}
+			if attribute == "last_net" && max <= 1 {
+				excludesSubnet = true
+			}
 			return ClumpingByAttribute(attr, int(max)), nil
 		},
 	}
 	filter, err := mito.Eval(expr, env)
 	if err != nil {
-		return nil, errs.New("Invalid invariant definition '%s', %v", expr, err)
+		return nil, false, errs.New("Invalid invariant definition '%s', %v", expr, err)
 	}
-	return filter.(Invariant), nil
+	return filter.(Invariant), excludesSubnet, nil
 }
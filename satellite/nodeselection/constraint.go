@@ -0,0 +1,103 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package nodeselection
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/common/storj/location"
+)
+
+// SetConstraint is a constraint on the full set of the nodes selected for a placement.
+//
+// Node filters and set constraints are evaluated in different phases: the filters decide which
+// nodes can be selected at all (before the selection), while the set constraints are checked on
+// the result of the selection. Set constraints are defined in the placement filter expression
+// (eg. `country("DE","FR","PL") && spread(country, 2)`) and they match every node there.
+type SetConstraint interface {
+	NodeFilter
+	// Check returns an error if the selected nodes don't satisfy the constraint.
+	Check(nodes []*SelectedNode) error
+}
+
+// ErrSetConstraint is returned when the selected nodes don't satisfy a set constraint.
+var ErrSetConstraint = errs.Class("set constraint")
+
+// CountrySpread requires the selected nodes to be in at least Min different countries.
+// Nodes without a known country are not counted.
+type CountrySpread struct {
+	Min int
+}
+
+// NewSpread creates the set constraint of spread(attribute, min). Only the country attribute is supported.
+func NewSpread(attribute string, min int64) (CountrySpread, error) {
+	if attribute != "country" {
+		return CountrySpread{}, ErrPlacement.New("spread() supports only the country attribute, got %q", attribute)
+	}
+	if min < 1 {
+		return CountrySpread{}, ErrPlacement.New("minimum of spread() should be positive, got %d", min)
+	}
+	return CountrySpread{Min: int(min)}, nil
+}
+
+// spreadFilter implements spread(attribute, min) of the placement DSL. The attribute can be
+// the country function itself (spread(country, 2)) or its name (spread("country", 2)).
+func spreadFilter(attribute any, min int64) (NodeFilter, error) {
+	switch value := attribute.(type) {
+	case string:
+		return NewSpread(value, min)
+	case func(...string) (NodeFilter, error):
+		if reflect.ValueOf(value).Pointer() == reflect.ValueOf(countryFilter).Pointer() {
+			return NewSpread("country", min)
+		}
+	}
+	return nil, ErrPlacement.New("spread() supports only the country attribute")
+}
+
+// Match implements NodeFilter. Set constraints don't restrict the individual nodes.
+func (c CountrySpread) Match(node *SelectedNode) bool {
+	return true
+}
+
+// Check implements SetConstraint.
+func (c CountrySpread) Check(nodes []*SelectedNode) error {
+	countries := map[location.CountryCode]struct{}{}
+	for _, node := range nodes {
+		if node.CountryCode != location.None {
+			countries[node.CountryCode] = struct{}{}
+		}
+	}
+	if len(countries) < c.Min {
+		return ErrSetConstraint.New("selected nodes are in %d countries, at least %d are required", len(countries), c.Min)
+	}
+	return nil
+}
+
+func (c CountrySpread) String() string {
+	return fmt.Sprintf(`spread(country,%d)`, c.Min)
+}
+
+var _ SetConstraint = CountrySpread{}
+
+// SetConstraints collects the set constraints of a filter. Constraints are collected only from
+// the filters which are required for every node (&&, all() and annotated()), as they are
+// meaningless under negation or alternatives.
+func SetConstraints(filter NodeFilter) (constraints []SetConstraint) {
+	switch f := filter.(type) {
+	case SetConstraint:
+		constraints = append(constraints, f)
+	case NodeFilters:
+		for _, filter := range f {
+			constraints = append(constraints, SetConstraints(filter)...)
+		}
+	case AnnotatedNodeFilter:
+		constraints = append(constraints, SetConstraints(f.Filter)...)
+	case Placement:
+		constraints = append(constraints, SetConstraints(f.NodeFilter)...)
+	}
+	return constraints
+}
@@ -8,12 +8,16 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zeebo/errs"
 
+	"storj.io/common/memory"
 	"storj.io/common/storj"
 	"storj.io/common/storj/location"
+	"storj.io/common/version"
 )
 
 // NodeFilter can decide if a Node should be part of the selection or not.
@@ -27,6 +31,25 @@ type NodeFilterWithAnnotation interface {
 	GetAnnotation(name string) string
 }
 
+// NodeFilterWithExplanation is a NodeFilter which can explain why a node isn't matched.
+// It's a debugging aid, node selection uses Match.
+type NodeFilterWithExplanation interface {
+	NodeFilter
+	MatchExplain(node *SelectedNode) (bool, string)
+}
+
+// MatchExplain matches the node and returns a human-readable reason when the node isn't matched.
+// Filters which can't explain themselves are reported with their definition.
+func MatchExplain(filter NodeFilter, node *SelectedNode) (bool, string) {
+	if explained, ok := filter.(NodeFilterWithExplanation); ok {
+		return explained.MatchExplain(node)
+	}
+	if filter.Match(node) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("node is not matched by %s", filter)
+}
+
 // Annotation can be used as node filters in 'XX && annotation('...')' like struct.
 type Annotation struct {
 	Key   string
@@ -76,12 +99,17 @@ func (a AnnotatedNodeFilter) Match(node *SelectedNode) bool {
 	return a.Filter.Match(node)
 }
 
+// MatchExplain implements NodeFilterWithExplanation.
+func (a AnnotatedNodeFilter) MatchExplain(node *SelectedNode) (bool, string) {
+	return MatchExplain(a.Filter, node)
+}
+
 func (a AnnotatedNodeFilter) String() string {
-	var annotationStr []string
+	parts := []string{fmt.Sprintf("%s", a.Filter)}
 	for _, annotation := range a.Annotations {
-		annotationStr = append(annotationStr, annotation.String())
+		parts = append(parts, annotation.String())
 	}
-	return fmt.Sprintf("%s && %s", a.Filter, strings.Join(annotationStr, " && "))
+	return fmt.Sprintf("annotated(%s)", strings.Join(parts, ","))
 }
 
 // WithAnnotation adds annotations to a NodeFilter.
@@ -106,6 +134,7 @@ func GetAnnotation(filter NodeFilter, name string) string {
 }
 
 var _ NodeFilterWithAnnotation = AnnotatedNodeFilter{}
+var _ NodeFilterWithExplanation = AnnotatedNodeFilter{}
 
 // NodeFilters is a collection of multiple node filters (all should vote with true).
 type NodeFilters []NodeFilter
@@ -124,6 +153,8 @@ type ExcludeAllFilter struct{}
 // Match implements NodeFilter interface.
 func (ExcludeAllFilter) Match(node *SelectedNode) bool { return false }
 
+func (ExcludeAllFilter) String() string { return "exclude(all())" }
+
 // Match implements NodeFilter interface.
 func (n NodeFilters) Match(node *SelectedNode) bool {
 	for _, filter := range n {
@@ -134,6 +165,16 @@ func (n NodeFilters) Match(node *SelectedNode) bool {
 	return true
 }
 
+// MatchExplain implements NodeFilterWithExplanation. The reason is the reason of the first rejecting filter.
+func (n NodeFilters) MatchExplain(node *SelectedNode) (bool, string) {
+	for _, filter := range n {
+		if ok, reason := MatchExplain(filter, node); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
 // OrFilter will include the node, if at lest one of the filters are matched.
 type OrFilter []NodeFilter
 
@@ -166,6 +207,9 @@ func (n NodeFilters) WithExcludedIDs(ds []storj.NodeID) NodeFilters {
 }
 
 func (n NodeFilters) String() string {
+	if len(n) == 0 {
+		return "all()"
+	}
 	if len(n) == 1 {
 		return fmt.Sprintf("%s", n[0])
 	}
@@ -192,10 +236,14 @@ func (n NodeFilters) GetAnnotation(name string) string {
 }
 
 var _ NodeFilterWithAnnotation = NodeFilters{}
+var _ NodeFilterWithExplanation = NodeFilters{}
 
 // CountryFilter can select nodes based on the condition of the country code.
 type CountryFilter struct {
 	permit location.Set
+	// unknown are the referenced country codes, which are valid codes, but not known countries
+	// (like QQ). They are accepted for compatibility, and reported by ConfigurablePlacementRule.Validate.
+	unknown []string
 }
 
 // NewCountryFilter creates a new CountryFilter.
@@ -208,7 +256,12 @@ func NewCountryFilter(permit location.Set) *CountryFilter {
 // NewCountryFilterFromString parses country definitions like 'hu','!hu','*','none' and creates a CountryFilter.
 func NewCountryFilterFromString(countries []string) (*CountryFilter, error) {
 	var set location.Set
-	for _, country := range countries {
+	var unknown []string
+	for _, token := range countries {
+		if token == "" {
+			return nil, errs.New("invalid country code %q", token)
+		}
+		country := token
 		apply := func(modified location.Set, code ...location.CountryCode) location.Set {
 			return modified.With(code...)
 		}
@@ -231,12 +284,32 @@ func NewCountryFilterFromString(countries []string) (*CountryFilter, error) {
 		default:
 			code := location.ToCountryCode(country)
 			if code == location.None {
-				return nil, errs.New("invalid country code %q", code)
+				return nil, errs.New("invalid country code %q", token)
+			}
+			if !knownCountry(code) {
+				unknown = append(unknown, token)
 			}
 			set = apply(set, code)
 		}
 	}
-	return NewCountryFilter(set), nil
+	filter := NewCountryFilter(set)
+	filter.unknown = unknown
+	return filter, nil
+}
+
+// knownCountry returns true if the country code has an ISO country.
+func knownCountry(code location.CountryCode) bool {
+	return int(code) < len(location.CountryISOCode) && location.CountryISOCode[code] != ""
+}
+
+// NewContinentFilter creates a CountryFilter which selects the countries of the named continent (see Continents).
+func NewContinentFilter(continent string) (*CountryFilter, error) {
+	countries, found := Continents[strings.ToLower(continent)]
+	if !found {
+		return nil, errs.New("unknown continent %q", continent)
+	}
+	var set location.Set
+	return NewCountryFilter(set.With(countries...)), nil
 }
 
 // Match implements NodeFilter interface.
@@ -244,6 +317,14 @@ func (p *CountryFilter) Match(node *SelectedNode) bool {
 	return p.permit.Contains(node.CountryCode)
 }
 
+// MatchExplain implements NodeFilterWithExplanation.
+func (p *CountryFilter) MatchExplain(node *SelectedNode) (bool, string) {
+	if p.Match(node) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("country %q of the node is not permitted by %s", node.CountryCode.String(), p)
+}
+
 func (p *CountryFilter) String() string {
 	var included, excluded []string
 	for country, iso := range location.CountryISOCode {
@@ -261,7 +342,7 @@ func (p *CountryFilter) String() string {
 	return fmt.Sprintf(`country("%s")`, strings.Join(included, `","`))
 }
 
-var _ NodeFilter = &CountryFilter{}
+var _ NodeFilterWithExplanation = &CountryFilter{}
 
 // ExcludedNetworks will exclude nodes with specified networks.
 type ExcludedNetworks []string
@@ -313,10 +394,16 @@ type ValueMatch func(a []byte, b []byte) bool
 
 // TagFilter matches nodes with specific tags.
 type TagFilter struct {
-	signer storj.NodeID
-	name   string
-	value  []byte
-	match  ValueMatch
+	signer    storj.NodeID
+	anySigner bool
+	name      string
+	value     []byte
+	match     ValueMatch
+
+	// valueExpr is the placement expression of the value, when it's not a plain string (like notEmpty() or gt(500)).
+	valueExpr string
+	// function is the placement function which created the filter, when it's not tag() or anytag() (like tagsuffix()).
+	function string
 }
 
 // NewTagFilter creates a new tag filter.
@@ -329,23 +416,67 @@ func NewTagFilter(id storj.NodeID, name string, value []byte, match ValueMatch)
 	}
 }
 
+// NewAnySignerTagFilter creates a new tag filter which accepts the tag regardless of the signer.
+// It's less secure than NewTagFilter: anybody who can sign a tag can make the node match.
+func NewAnySignerTagFilter(name string, value []byte, match ValueMatch) TagFilter {
+	return TagFilter{
+		anySigner: true,
+		name:      name,
+		value:     value,
+		match:     match,
+	}
+}
+
 // Match implements NodeFilter interface.
 func (t TagFilter) Match(node *SelectedNode) bool {
 	for _, tag := range node.Tags {
-		if tag.Name == t.name && t.match(tag.Value, t.value) && tag.Signer == t.signer {
+		if tag.Name == t.name && t.match(tag.Value, t.value) && (t.anySigner || tag.Signer == t.signer) {
 			return true
 		}
 	}
 	return false
 }
 
+// MatchExplain implements NodeFilterWithExplanation.
+func (t TagFilter) MatchExplain(node *SelectedNode) (bool, string) {
+	var values []string
+	for _, tag := range node.Tags {
+		if tag.Name != t.name || (!t.anySigner && tag.Signer != t.signer) {
+			continue
+		}
+		if t.match(tag.Value, t.value) {
+			return true, ""
+		}
+		values = append(values, fmt.Sprintf("%q", tag.Value))
+	}
+	if len(values) == 0 {
+		if t.anySigner {
+			return false, fmt.Sprintf("node has no %q tag, required by %s", t.name, t)
+		}
+		return false, fmt.Sprintf("node has no %q tag signed by %s, required by %s", t.name, t.signer, t)
+	}
+	return false, fmt.Sprintf("value %s of the %q tag is not matched by %s", strings.Join(values, ", "), t.name, t)
+}
+
 func (t TagFilter) String() string {
-	return fmt.Sprintf(`tag("%s","%s","%s")`, t.signer, t.name, string(t.value))
+	if t.function != "" {
+		return fmt.Sprintf(`%s("%s","%s","%s")`, t.function, t.signer, t.name, string(t.value))
+	}
+	value := t.valueExpr
+	if value == "" {
+		value = fmt.Sprintf(`"%s"`, string(t.value))
+	}
+	if t.anySigner {
+		return fmt.Sprintf(`anytag("%s",%s)`, t.name, value)
+	}
+	return fmt.Sprintf(`tag("%s","%s",%s)`, t.signer, t.name, value)
 }
 
-var _ NodeFilter = TagFilter{}
+var _ NodeFilterWithExplanation = TagFilter{}
 
-// ExcludeFilter excludes only the matched nodes.
+// ExcludeFilter excludes only the matched nodes. The wrapped filter can be a composite
+// (like NodeFilters, created by &&), in which case only the nodes matching the whole
+// composite are excluded.
 type ExcludeFilter struct {
 	matchToExclude NodeFilter
 }
@@ -355,6 +486,14 @@ func (e ExcludeFilter) Match(node *SelectedNode) bool {
 	return !e.matchToExclude.Match(node)
 }
 
+// MatchExplain implements NodeFilterWithExplanation.
+func (e ExcludeFilter) MatchExplain(node *SelectedNode) (bool, string) {
+	if e.Match(node) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("node is excluded by %s", e)
+}
+
 func (e ExcludeFilter) String() string {
 	return fmt.Sprintf("exclude(%s)", e.matchToExclude)
 }
@@ -366,7 +505,223 @@ func NewExcludeFilter(filter NodeFilter) ExcludeFilter {
 	}
 }
 
-var _ NodeFilter = ExcludeFilter{}
+var _ NodeFilterWithExplanation = ExcludeFilter{}
+
+// NotFilter negates the wrapped filter. Annotations of the wrapped filter are kept.
+type NotFilter struct {
+	filter NodeFilter
+}
+
+// NewNotFilter creates a filter which matches only the nodes not matched by the given filter.
+func NewNotFilter(filter NodeFilter) NotFilter {
+	return NotFilter{
+		filter: filter,
+	}
+}
+
+// Match implements NodeFilter interface.
+func (n NotFilter) Match(node *SelectedNode) bool {
+	return !n.filter.Match(node)
+}
+
+// GetAnnotation implements NodeFilterWithAnnotation.
+func (n NotFilter) GetAnnotation(name string) string {
+	return GetAnnotation(n.filter, name)
+}
+
+func (n NotFilter) String() string {
+	return fmt.Sprintf("not(%s)", n.filter)
+}
+
+var _ NodeFilterWithAnnotation = NotFilter{}
+
+// versionOperators are the supported comparison operators of the version constraints.
+// Longer operators are first, so they are matched before their prefixes.
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// VersionFilter matches the nodes which software version satisfies a constraint (like >=1.95.0).
+type VersionFilter struct {
+	operator string
+	version  version.SemVer
+}
+
+// NewVersionFilter creates a filter from a version constraint, which is a comparison operator
+// (>=, <=, !=, >, <, =) followed by a semantic version. The operator defaults to =.
+func NewVersionFilter(constraint string) (VersionFilter, error) {
+	constraint = strings.TrimSpace(constraint)
+	operator := "="
+	for _, op := range versionOperators {
+		if strings.HasPrefix(constraint, op) {
+			operator = op
+			constraint = strings.TrimSpace(strings.TrimPrefix(constraint, op))
+			break
+		}
+	}
+	ver, err := version.NewSemVer(constraint)
+	if err != nil {
+		return VersionFilter{}, ErrPlacement.New("invalid version constraint %q: %v", operator+constraint, err)
+	}
+	return VersionFilter{
+		operator: operator,
+		version:  ver,
+	}, nil
+}
+
+// Match implements NodeFilter interface.
+func (v VersionFilter) Match(node *SelectedNode) bool {
+	c := node.Version.Compare(v.version)
+	switch v.operator {
+	case ">=":
+		return c >= 0
+	case "<=":
+		return c <= 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case "<":
+		return c < 0
+	default:
+		return c == 0
+	}
+}
+
+func (v VersionFilter) String() string {
+	return fmt.Sprintf(`version("%s%s")`, v.operator, strings.TrimPrefix(v.version.String(), "v"))
+}
+
+var _ NodeFilter = VersionFilter{}
+
+// LastContactFilter matches the nodes which were successfully contacted within the given duration.
+// The last contact time is loaded with the nodes, so it's as fresh as the node selection cache.
+type LastContactFilter struct {
+	maxAge time.Duration
+}
+
+// NewLastContactFilter creates a filter from a duration like 2h (parsed with time.ParseDuration).
+func NewLastContactFilter(maxAge string) (LastContactFilter, error) {
+	duration, err := time.ParseDuration(maxAge)
+	if err != nil {
+		return LastContactFilter{}, ErrPlacement.New("invalid duration of lastContact(): %v", err)
+	}
+	if duration <= 0 {
+		return LastContactFilter{}, ErrPlacement.New("duration of lastContact() should be positive, got %s", maxAge)
+	}
+	return LastContactFilter{
+		maxAge: duration,
+	}, nil
+}
+
+// Match implements NodeFilter interface.
+func (l LastContactFilter) Match(node *SelectedNode) bool {
+	return !node.LastContactSuccess.IsZero() && time.Since(node.LastContactSuccess) <= l.maxAge
+}
+
+func (l LastContactFilter) String() string {
+	return fmt.Sprintf(`lastContact("%s")`, l.maxAge)
+}
+
+var _ NodeFilter = LastContactFilter{}
+
+// VettedFilter matches the nodes based on their vetting status.
+type VettedFilter struct {
+	vetted bool
+}
+
+// NewVettedFilter creates a filter which matches the vetted nodes, or the unvetted nodes when vetted is false.
+func NewVettedFilter(vetted bool) VettedFilter {
+	return VettedFilter{
+		vetted: vetted,
+	}
+}
+
+// Match implements NodeFilter interface.
+func (v VettedFilter) Match(node *SelectedNode) bool {
+	return node.Vetted == v.vetted
+}
+
+func (v VettedFilter) String() string {
+	return fmt.Sprintf("vetted(%t)", v.vetted)
+}
+
+var _ NodeFilter = VettedFilter{}
+
+// FreeDiskFilter matches the nodes which reported at least the given free disk space.
+// The free disk space is loaded with the nodes, so it's as fresh as the node selection cache.
+type FreeDiskFilter struct {
+	minFreeDisk int64
+}
+
+// NewFreeDiskFilter creates a filter from a size like 10GB (parsed with memory.ParseString).
+func NewFreeDiskFilter(size string) (FreeDiskFilter, error) {
+	// memory.ParseString doesn't handle sizes without a number (like "GB").
+	if !strings.ContainsAny(size, "0123456789") {
+		return FreeDiskFilter{}, ErrPlacement.New("invalid size of minFreeDisk(): %q", size)
+	}
+	minFreeDisk, err := memory.ParseString(size)
+	if err != nil {
+		return FreeDiskFilter{}, ErrPlacement.New("invalid size of minFreeDisk(): %v", err)
+	}
+	if minFreeDisk <= 0 {
+		return FreeDiskFilter{}, ErrPlacement.New("size of minFreeDisk() should be positive, got %s", size)
+	}
+	return FreeDiskFilter{
+		minFreeDisk: minFreeDisk,
+	}, nil
+}
+
+// Match implements NodeFilter interface.
+func (f FreeDiskFilter) Match(node *SelectedNode) bool {
+	return node.FreeDisk >= f.minFreeDisk
+}
+
+func (f FreeDiskFilter) String() string {
+	return fmt.Sprintf(`minFreeDisk("%dB")`, f.minFreeDisk)
+}
+
+var _ NodeFilter = FreeDiskFilter{}
+
+// ThresholdFilter matches the nodes which are matched by at least threshold of the filters.
+type ThresholdFilter struct {
+	threshold int
+	filters   []NodeFilter
+}
+
+// NewThresholdFilter creates a filter which matches the nodes matched by at least threshold of the given filters.
+// The threshold should be between 1 and the number of the filters.
+func NewThresholdFilter(threshold int, filters ...NodeFilter) (ThresholdFilter, error) {
+	if threshold < 1 || threshold > len(filters) {
+		return ThresholdFilter{}, ErrPlacement.New("threshold of atLeast() should be between 1 and the number of filters (%d), got %d", len(filters), threshold)
+	}
+	return ThresholdFilter{
+		threshold: threshold,
+		filters:   filters,
+	}, nil
+}
+
+// Match implements NodeFilter interface.
+func (t ThresholdFilter) Match(node *SelectedNode) bool {
+	matched := 0
+	for _, filter := range t.filters {
+		if filter.Match(node) {
+			matched++
+			if matched >= t.threshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t ThresholdFilter) String() string {
+	parts := []string{strconv.Itoa(t.threshold)}
+	for _, filter := range t.filters {
+		parts = append(parts, fmt.Sprintf("%s", filter))
+	}
+	return "atLeast(" + strings.Join(parts, ",") + ")"
+}
+
+var _ NodeFilter = ThresholdFilter{}
 
 // AnyFilter matches all the nodes.
 type AnyFilter struct{}
@@ -376,6 +731,10 @@ func (a AnyFilter) Match(node *SelectedNode) bool {
 	return true
 }
 
+func (a AnyFilter) String() string {
+	return "all()"
+}
+
 var _ NodeFilter = AnyFilter{}
 
 // AllowedNodesFilter is a special filter which enables only the selected nodes.
@@ -4,6 +4,7 @@
 package nodeselection
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -33,6 +34,39 @@ func TestCriteria_ExcludeNodeID(t *testing.T) {
 	}))
 }
 
+func TestExcludeFilter_Composite(t *testing.T) {
+	signer := testidentity.MustPregeneratedIdentity(0, storj.LatestIDVersion()).ID
+	taggedNode := func(countryCode location.CountryCode, value string) *SelectedNode {
+		return &SelectedNode{
+			CountryCode: countryCode,
+			Tags: NodeTags{
+				{Signer: signer, Name: "tier", Value: []byte(value)},
+			},
+		}
+	}
+
+	filter := NewExcludeFilter(NodeFilters{
+		NewCountryFilter(location.NewSet(location.Germany)),
+		NewTagFilter(signer, "tier", []byte("low"), bytes.Equal),
+	})
+
+	// only the nodes matching the whole composite are excluded.
+	assert.False(t, filter.Match(taggedNode(location.Germany, "low")))
+	assert.True(t, filter.Match(taggedNode(location.Germany, "high")))
+	assert.True(t, filter.Match(taggedNode(location.UnitedKingdom, "low")))
+	assert.True(t, filter.Match(&SelectedNode{CountryCode: location.Germany}))
+
+	nested := NewExcludeFilter(NodeFilters{
+		NewCountryFilter(location.NewSet(location.Germany)),
+		NodeFilters{NewTagFilter(signer, "tier", []byte("low"), bytes.Equal)},
+	})
+	assert.False(t, nested.Match(taggedNode(location.Germany, "low")))
+	assert.True(t, nested.Match(taggedNode(location.Germany, "high")))
+
+	// an empty composite matches all the nodes, so all of them are excluded.
+	assert.False(t, NewExcludeFilter(NodeFilters{}).Match(taggedNode(location.Germany, "low")))
+}
+
 func TestCriteria_ExcludedNodeNetworks(t *testing.T) {
 	criteria := NodeFilters{}
 	criteria = append(criteria, ExcludedNodeNetworks{
@@ -190,6 +224,49 @@ func TestCountryFilter_FromString(t *testing.T) {
 	}
 }
 
+func TestCountryFilter_InvalidCode(t *testing.T) {
+	_, err := NewCountryFilterFromString([]string{"DE", "XZZ"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"XZZ"`)
+
+	_, err = NewCountryFilterFromString([]string{"*", "!ZZZZ"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"!ZZZZ"`)
+
+	p := PlacementDefinitions{}
+	err = p.AddPlacementFromString(`10:country("ZZZZ")`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"ZZZZ"`)
+}
+
+func TestContinentFilter(t *testing.T) {
+	seen := map[location.CountryCode]string{}
+	for continent, countries := range Continents {
+		filter, err := NewContinentFilter(continent)
+		require.NoError(t, err, continent)
+
+		for _, country := range countries {
+			require.NotEqual(t, location.None, country)
+			require.True(t, filter.Match(&SelectedNode{
+				CountryCode: country,
+			}), "%s should be part of %s", country, continent)
+
+			other, found := seen[country]
+			require.False(t, found, "%s is part of both %s and %s", country, continent, other)
+			seen[country] = continent
+		}
+	}
+
+	filter, err := NewContinentFilter("Europe")
+	require.NoError(t, err)
+	require.True(t, filter.Match(&SelectedNode{CountryCode: location.Germany}))
+	require.True(t, filter.Match(&SelectedNode{CountryCode: location.Kosovo}))
+	require.False(t, filter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+
+	_, err = NewContinentFilter("atlantis")
+	require.Error(t, err)
+}
+
 func TestNodeListFilter(t *testing.T) {
 	filter, err := AllowedNodesFromFile("filter_testdata.txt")
 	require.NoError(t, err)
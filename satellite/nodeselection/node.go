@@ -15,6 +15,7 @@ import (
 	"storj.io/common/pb"
 	"storj.io/common/storj"
 	"storj.io/common/storj/location"
+	"storj.io/common/version"
 )
 
 // NodeTag is a tag associated with a node (approved by signer).
@@ -52,7 +53,13 @@ type SelectedNode struct {
 	Suspended   bool
 	Online      bool
 	Vetted      bool
-	Tags        NodeTags
+	Version     version.SemVer
+	// LastContactSuccess is the time of the last successful contact with the node,
+	// as of loading the node from the database.
+	LastContactSuccess time.Time
+	// FreeDisk is the free disk space of the node in bytes, as reported at its last check-in.
+	FreeDisk int64
+	Tags     NodeTags
 }
 
 // Clone returns a deep clone of the selected node.
@@ -5,9 +5,16 @@ package nodeselection
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode"
 
 	"github.com/jtolio/mito"
 	"github.com/spf13/pflag"
@@ -29,6 +36,12 @@ type Placement struct {
 	Selector NodeSelectorInit
 	// checked by repair job, applied to the full selection. Out of placement items will be replaced by new, selected by the Selector.
 	Invariant Invariant
+	// ExcludeSameSubnet is set when the Selector or the Invariant doesn't allow multiple nodes from the same subnet.
+	// It's decided when the placement is defined: by the autoExcludeSubnet annotation of the placement definitions,
+	// or by the configured selector and invariant of the YAML configuration.
+	ExcludeSameSubnet bool
+	// seeded placements (legacy and test defaults) can be redefined by the placement definitions.
+	seeded bool
 }
 
 // Match implements NodeFilter.
@@ -65,6 +78,9 @@ type NodeSelector func(n int, excluded []storj.NodeID, alreadySelected []*Select
 // ErrPlacement is used for placement definition related parsing errors.
 var ErrPlacement = errs.Class("placement")
 
+// ErrUnknownPlacement is used when a placement is used without having placement rules.
+var ErrUnknownPlacement = errs.Class("unknown placement")
+
 // PlacementRules can crate filter based on the placement identifier.
 type PlacementRules func(constraint storj.PlacementConstraint) (filter NodeFilter)
 
@@ -74,6 +90,12 @@ type PlacementDefinitions map[storj.PlacementConstraint]Placement
 // ConfigurablePlacementRule is a string configuration includes all placement rules in the form of id1:def1,id2:def2...
 type ConfigurablePlacementRule struct {
 	PlacementRules string
+	// AllowOverride allows the same placement ID to be defined multiple times, the last definition wins.
+	// It can't be set with the flag itself, the satellite sets it from the placement-allow-override config.
+	AllowOverride bool
+	// AllowEveryCountryOverride allows redefining placement 0 (storj.EveryCountry), which is unrestricted by default.
+	// It can't be set with the flag itself, the satellite sets it from the placement-allow-every-country-override config.
+	AllowEveryCountryOverride bool
 }
 
 // String implements pflag.Value.
@@ -111,31 +133,196 @@ func (c ConfigurablePlacementRule) Parse(defaultPlacement func() (Placement, err
 			return LoadConfig(rules)
 
 		}
-		ruleBytes, err := os.ReadFile(rules)
-		if err != nil {
-			return nil, ErrPlacement.New("Placement definition file couldn't be read: %s %v", rules, err)
-		}
-		rules = string(ruleBytes)
+		d := PlacementDefinitions(map[storj.PlacementConstraint]Placement{})
+		d.AddLegacyStaticRules()
+		err := d.addPlacementFromFile(rules, c.options())
+		return d, err
 	}
 	if strings.HasPrefix(rules, "/") || strings.HasPrefix(rules, "./") || strings.HasPrefix(rules, "../") {
 		return nil, ErrPlacement.New("Placement definition (%s) looks to be a path, but file doesn't exist at that place", rules)
 	}
 	d := PlacementDefinitions(map[storj.PlacementConstraint]Placement{})
 	d.AddLegacyStaticRules()
-	err := d.AddPlacementFromString(rules)
+	err := d.addPlacementFromString(rules, c.options())
 	return d, err
 }
 
 var _ pflag.Value = &ConfigurablePlacementRule{}
 
+// Explain parses the rules and explains why the node is (not) matched by the placement,
+// see PlacementDefinitions.Explain.
+func (c ConfigurablePlacementRule) Explain(defaultPlacement func() (Placement, error), id storj.PlacementConstraint, node *SelectedNode) (matched bool, reason string, err error) {
+	definitions, err := c.Parse(defaultPlacement)
+	if err != nil {
+		return false, "", err
+	}
+	return definitions.Explain(id, node)
+}
+
+// PlacementConstraints parses the rules and returns the set constraints of the placement,
+// see PlacementDefinitions.PlacementConstraints.
+func (c ConfigurablePlacementRule) PlacementConstraints(id storj.PlacementConstraint) ([]SetConstraint, error) {
+	if c.PlacementRules == "" {
+		// the default placements don't have set constraints.
+		return nil, nil
+	}
+	definitions, err := c.Parse(nil)
+	if err != nil {
+		return nil, err
+	}
+	return definitions.PlacementConstraints(id), nil
+}
+
+// Validate parses the rules and checks that every country code referenced by the filters is a known country.
+// Unknown codes (like QQ) are accepted by the parser, but they never match any node. All the problems are
+// returned at once, so the configuration can be checked before deployment.
+func (c ConfigurablePlacementRule) Validate() error {
+	if c.PlacementRules == "" {
+		return nil
+	}
+	definitions, err := c.Parse(nil)
+	if err != nil {
+		return err
+	}
+
+	var group errs.Group
+	for _, id := range definitions.SupportedPlacements() {
+		walkFilters(definitions[id].NodeFilter, func(filter NodeFilter) {
+			if country, ok := filter.(*CountryFilter); ok {
+				for _, code := range country.unknown {
+					group.Add(ErrPlacement.New("placement %d: unknown country code %q", id, code))
+				}
+			}
+		})
+	}
+	return group.Err()
+}
+
+// walkFilters calls fn with the filter and all the filters nested in it.
+func walkFilters(filter NodeFilter, fn func(filter NodeFilter)) {
+	fn(filter)
+	var nested []NodeFilter
+	switch f := filter.(type) {
+	case NodeFilters:
+		nested = f
+	case OrFilter:
+		nested = f
+	case AnnotatedNodeFilter:
+		nested = []NodeFilter{f.Filter}
+	case ExcludeFilter:
+		nested = []NodeFilter{f.matchToExclude}
+	case NotFilter:
+		nested = []NodeFilter{f.filter}
+	case ThresholdFilter:
+		nested = f.filters
+	case Placement:
+		nested = []NodeFilter{f.NodeFilter}
+	}
+	for _, filter := range nested {
+		walkFilters(filter, fn)
+	}
+}
+
+// options returns the options of parsing the placement rules.
+func (c ConfigurablePlacementRule) options() placementOptions {
+	return placementOptions{
+		allowOverride:             c.AllowOverride,
+		allowEveryCountryOverride: c.AllowEveryCountryOverride,
+	}
+}
+
+// placementRuleJSON is the JSON representation of a placement rule.
+type placementRuleJSON struct {
+	ID          storj.PlacementConstraint `json:"id"`
+	Definition  string                    `json:"definition"`
+	Annotations map[string]string         `json:"annotations,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The rules are encoded as an array of
+// {id, definition, annotations} objects ordered by id. Only the node filters of the placements
+// are encoded, the implicit legacy placements are omitted.
+func (c ConfigurablePlacementRule) MarshalJSON() ([]byte, error) {
+	rules := []placementRuleJSON{}
+	if c.PlacementRules != "" {
+		definitions, err := c.Parse(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]storj.PlacementConstraint, 0, len(definitions))
+		for id, placement := range definitions {
+			if !placement.seeded {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			rule := placementRuleJSON{ID: id}
+			filter := definitions[id].NodeFilter
+			if annotated, ok := filter.(AnnotatedNodeFilter); ok {
+				filter = annotated.Filter
+				rule.Annotations = map[string]string{}
+				for _, annotation := range annotated.Annotations {
+					rule.Annotations[annotation.Key] = annotation.Value
+				}
+			}
+			rule.Definition = fmt.Sprintf("%s", filter)
+			rules = append(rules, rule)
+		}
+	}
+	return json.Marshal(rules)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts the representation of MarshalJSON
+// and fails if any of the definitions is invalid.
+func (c *ConfigurablePlacementRule) UnmarshalJSON(data []byte) error {
+	var rules []placementRuleJSON
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return ErrPlacement.Wrap(err)
+	}
+
+	var definitions []string
+	for _, rule := range rules {
+		definition := rule.Definition
+		if len(rule.Annotations) > 0 {
+			keys := make([]string, 0, len(rule.Annotations))
+			for key := range rule.Annotations {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			parts := []string{definition}
+			for _, key := range keys {
+				parts = append(parts, Annotation{Key: key, Value: rule.Annotations[key]}.String())
+			}
+			definition = fmt.Sprintf("annotated(%s)", strings.Join(parts, ","))
+		}
+		definitions = append(definitions, fmt.Sprintf("%d:%s", rule.ID, definition))
+	}
+	placementRules := strings.Join(definitions, ";")
+
+	d := PlacementDefinitions{}
+	d.AddLegacyStaticRules()
+	if err := d.addPlacementFromString(placementRules, c.options()); err != nil {
+		return err
+	}
+
+	c.PlacementRules = placementRules
+	return nil
+}
+
 // TestPlacementDefinitions creates placements for testing. Only 0 placement is defined with subnetfiltering.
+// The 0 placement can be redefined by placement definitions.
 func TestPlacementDefinitions() PlacementDefinitions {
 	return map[storj.PlacementConstraint]Placement{
 		storj.DefaultPlacement: {
-			ID:         storj.DefaultPlacement,
-			NodeFilter: AnyFilter{},
-			Selector:   AttributeGroupSelector(LastNetAttribute),
-			Invariant:  ClumpingByAttribute(LastNetAttribute, 1),
+			ID:                storj.DefaultPlacement,
+			NodeFilter:        AnyFilter{},
+			Selector:          AttributeGroupSelector(LastNetAttribute),
+			Invariant:         ClumpingByAttribute(LastNetAttribute, 1),
+			ExcludeSameSubnet: true,
+			seeded:            true,
 		},
 	}
 }
@@ -144,9 +331,11 @@ func TestPlacementDefinitions() PlacementDefinitions {
 func TestPlacementDefinitionsWithFraction(newNodeFraction float64) PlacementDefinitions {
 	return map[storj.PlacementConstraint]Placement{
 		storj.DefaultPlacement: {
-			ID:         storj.DefaultPlacement,
-			NodeFilter: AnyFilter{},
-			Selector:   UnvettedSelector(newNodeFraction, AttributeGroupSelector(LastNetAttribute)),
+			ID:                storj.DefaultPlacement,
+			NodeFilter:        AnyFilter{},
+			Selector:          UnvettedSelector(newNodeFraction, AttributeGroupSelector(LastNetAttribute)),
+			ExcludeSameSubnet: true,
+			seeded:            true,
 		},
 	}
 }
@@ -161,21 +350,27 @@ func NewPlacementDefinitions(placements ...Placement) PlacementDefinitions {
 }
 
 // AddLegacyStaticRules initializes all the placement rules defined earlier in static golang code.
+// These rules can be redefined by placement definitions.
 func (d PlacementDefinitions) AddLegacyStaticRules() {
 	d[storj.EEA] = Placement{
 		NodeFilter: NodeFilters{NewCountryFilter(location.NewSet(EeaCountriesWithoutEu...).With(EuCountries...))},
+		seeded:     true,
 	}
 	d[storj.EU] = Placement{
 		NodeFilter: NodeFilters{NewCountryFilter(location.NewSet(EuCountries...))},
+		seeded:     true,
 	}
 	d[storj.US] = Placement{
 		NodeFilter: NodeFilters{NewCountryFilter(location.NewSet(location.UnitedStates))},
+		seeded:     true,
 	}
 	d[storj.DE] = Placement{
 		NodeFilter: NodeFilters{NewCountryFilter(location.NewSet(location.Germany))},
+		seeded:     true,
 	}
 	d[storj.NR] = Placement{
 		NodeFilter: NodeFilters{NewCountryFilter(location.NewFullSet().Without(location.Russia, location.Belarus, location.None))},
+		seeded:     true,
 	}
 }
 
@@ -187,29 +382,323 @@ func (d PlacementDefinitions) AddPlacement(id storj.PlacementConstraint, placeme
 // AddPlacementRule registers a new placement.
 func (d PlacementDefinitions) AddPlacementRule(id storj.PlacementConstraint, filter NodeFilter) {
 	placement := Placement{
-		NodeFilter: filter,
-		Selector:   AttributeGroupSelector(LastNetAttribute),
-		Invariant:  ClumpingByAttribute(LastNetAttribute, 1),
+		NodeFilter:        filter,
+		Selector:          AttributeGroupSelector(LastNetAttribute),
+		Invariant:         ClumpingByAttribute(LastNetAttribute, 1),
+		ExcludeSameSubnet: true,
 	}
 	if GetAnnotation(filter, AutoExcludeSubnet) == AutoExcludeSubnetOFF {
 		placement.Selector = RandomSelector()
+		placement.ExcludeSameSubnet = false
 	}
 	d[id] = placement
 }
 
+// AddPlacementWithName registers a new placement with a human-readable name.
+func (d PlacementDefinitions) AddPlacementWithName(id storj.PlacementConstraint, name string, filter NodeFilter) {
+	d.AddPlacementRule(id, filter)
+	placement := d[id]
+	placement.Name = name
+	d[id] = placement
+}
+
+// PlacementName returns the human-readable name of the placement, if the placement is defined and has a name.
+func (d PlacementDefinitions) PlacementName(id storj.PlacementConstraint) (string, bool) {
+	placement, found := d[id]
+	if !found || placement.Name == "" {
+		return "", false
+	}
+	return placement.Name, true
+}
+
 type stringNotMatch string
 
+// tagComparison is a numeric comparison of tag values, created by gt(), gte(), lt() and lte().
+type tagComparison struct {
+	operator string
+	compare  func(value, limit int64) bool
+	limit    int64
+}
+
+// match implements ValueMatch. Tag values which are not integers never match.
+func (c tagComparison) match(a []byte, _ []byte) bool {
+	value, err := strconv.ParseInt(string(a), 10, 64)
+	if err != nil {
+		return false
+	}
+	return c.compare(value, c.limit)
+}
+
+func tagGreaterThan(limit int64) tagComparison {
+	return tagComparison{operator: "gt", compare: func(value, limit int64) bool { return value > limit }, limit: limit}
+}
+
+func tagGreaterOrEqual(limit int64) tagComparison {
+	return tagComparison{operator: "gte", compare: func(value, limit int64) bool { return value >= limit }, limit: limit}
+}
+
+func tagLessThan(limit int64) tagComparison {
+	return tagComparison{operator: "lt", compare: func(value, limit int64) bool { return value < limit }, limit: limit}
+}
+
+func tagLessOrEqual(limit int64) tagComparison {
+	return tagComparison{operator: "lte", compare: func(value, limit int64) bool { return value <= limit }, limit: limit}
+}
+
+// tagValueMatch returns the value, the matching method and the string representation
+// of the 3rd argument of tag().
+func tagValueMatch(value any) (rawValue []byte, match ValueMatch, valueExpr string, err error) {
+	match = bytes.Equal
+	switch v := value.(type) {
+	case string:
+		rawValue = []byte(v)
+	case []byte:
+		rawValue = v
+	case int64:
+		rawValue = []byte(strconv.FormatInt(v, 10))
+		valueExpr = string(rawValue)
+	case int:
+		rawValue = []byte(strconv.Itoa(v))
+		valueExpr = string(rawValue)
+	case stringNotMatch:
+		match = func(a, b []byte) bool {
+			return !bytes.Equal(a, b)
+		}
+		rawValue = []byte(v)
+		valueExpr = "notEmpty()"
+	case tagComparison:
+		match = v.match
+		rawValue = []byte(strconv.FormatInt(v.limit, 10))
+		valueExpr = fmt.Sprintf("%s(%d)", v.operator, v.limit)
+	default:
+		return nil, nil, "", ErrPlacement.New("3rd argument of tag() should be string, []byte, integer or comparison")
+	}
+	return rawValue, match, valueExpr, nil
+}
+
+// tagAffixFilter creates the filter of tagprefix() and tagsuffix(), which match the tags
+// with the given prefix or suffix.
+func tagAffixFilter(function string, nodeIDstr string, key string, affix string, match ValueMatch) (NodeFilters, error) {
+	if affix == "" {
+		return nil, ErrPlacement.New("3rd argument of %s() should be a non-empty string", function)
+	}
+
+	nodeID, err := storj.NodeIDFromString(nodeIDstr)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := NewTagFilter(nodeID, key, []byte(affix), match)
+	filter.function = function
+	return NodeFilters{
+		filter,
+	}, nil
+}
+
 // AddPlacementFromString parses placement definition form string representations from id:definition;id:definition;...
+// The same placement ID can't be defined multiple times, and already defined placements can't be
+// redefined, except the legacy static rules and the default placement of TestPlacementDefinitions.
+// Placement 0 (storj.EveryCountry) can't be defined, use ConfigurablePlacementRule.AllowEveryCountryOverride
+// to redefine it.
 // Deprecated: we will switch to the YAML based configuration.
 func (d PlacementDefinitions) AddPlacementFromString(definitions string) error {
+	return d.addPlacementFromString(definitions, placementOptions{})
+}
+
+// AddPlacementFromFile parses placement definitions from a file. Empty lines and lines starting with # are ignored.
+// Definitions can be separated by semicolons or newlines (id:definition per line), and a definition can be
+// continued on the following lines.
+func (d PlacementDefinitions) AddPlacementFromFile(path string) error {
+	return d.addPlacementFromFile(path, placementOptions{})
+}
+
+// addPlacementFromFile parses the placement definitions of a file. See AddPlacementFromFile.
+func (d PlacementDefinitions) addPlacementFromFile(path string, options placementOptions) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return ErrPlacement.New("Placement definition file couldn't be read: %s %v", path, err)
+	}
+
+	var sources []placementSource
+
+	var definition strings.Builder
+	var definitionLine int
+	flush := func() {
+		if definition.Len() == 0 {
+			return
+		}
+		for _, def := range strings.Split(definition.String(), ";") {
+			sources = append(sources, placementSource{
+				definition: def,
+				location:   fmt.Sprintf("%s:%d", path, definitionLine),
+			})
+		}
+		definition.Reset()
+	}
+
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if placementIDPrefix.MatchString(line) || strings.HasPrefix(line, namedFilterPrefix) || definition.Len() == 0 {
+			flush()
+			definitionLine = i + 1
+		} else {
+			definition.WriteString(" ")
+		}
+		definition.WriteString(line)
+	}
+	flush()
+
+	return d.addPlacementDefinitions(sources, options)
+}
+
+// placementIDPrefix matches lines which start a new placement definition.
+var placementIDPrefix = regexp.MustCompile(`^[0-9]+\s*:`)
+
+// namedFilterPrefix is the beginning of the named filter definitions, which are used
+// instead of an id:definition to define a filter reusable with ref(name).
+const namedFilterPrefix = "define("
+
+// placementSource is a single id:definition placement definition.
+type placementSource struct {
+	definition string
+	// location is where the definition comes from (eg. file:line), used in error messages.
+	location string
+}
+
+// placementOptions are the options of parsing the placement definitions.
+type placementOptions struct {
+	// allowOverride allows defining the same ID multiple times, later definitions replace the earlier ones.
+	allowOverride bool
+	// allowEveryCountryOverride allows defining placement 0 (storj.EveryCountry).
+	allowEveryCountryOverride bool
+}
+
+// addPlacementFromString parses placement definitions with the given options.
+func (d PlacementDefinitions) addPlacementFromString(definitions string, options placementOptions) error {
+	var sources []placementSource
+	for _, definition := range strings.Split(definitions, ";") {
+		sources = append(sources, placementSource{definition: definition})
+	}
+	return d.addPlacementDefinitions(sources, options)
+}
+
+// normalizeWhitespace trims the definition and replaces the whitespace sequences (including newlines)
+// outside of the string literals with a single space, so definitions can be formatted on multiple lines.
+func normalizeWhitespace(definition string) string {
+	var normalized strings.Builder
+	var inString, escaped, space bool
+	for _, r := range strings.TrimSpace(definition) {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			}
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '"':
+			inString = true
+		}
+		if space {
+			normalized.WriteRune(' ')
+			space = false
+		}
+		normalized.WriteRune(r)
+	}
+	return normalized.String()
+}
+
+// addPlacementDefinitions parses placement definitions. Definitions can reference each other
+// with placement(id) regardless of their order, but references can't form a cycle.
+// Named filters (define(name, filter)) are evaluated in order before the placements, and can be used
+// by the placements and the later named filters with ref(name).
+func (d PlacementDefinitions) addPlacementDefinitions(sources []placementSource, options placementOptions) error {
+	type pendingPlacement struct {
+		placementSource
+		expression string
+	}
+
+	// first pass: collect the definitions, they are evaluated on demand.
+	pending := map[storj.PlacementConstraint]pendingPlacement{}
+	var order []storj.PlacementConstraint
+	var defines []placementSource
+	for _, source := range sources {
+		definition := normalizeWhitespace(source.definition)
+		if definition == "" {
+			continue
+		}
+		source.definition = definition
+
+		if strings.HasPrefix(definition, namedFilterPrefix) {
+			defines = append(defines, source)
+			continue
+		}
+
+		withLocation := func(err error) error {
+			if source.location == "" {
+				return err
+			}
+			return ErrPlacement.New("%s: %v", source.location, errs.Unwrap(err))
+		}
+
+		idDef := strings.SplitN(definition, ":", 2)
+		if len(idDef) != 2 {
+			return withLocation(ErrPlacement.New("placement definition should be in the form ID:definition (but it was %s)", definition))
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(idDef[0]))
+		if err != nil {
+			return withLocation(ErrPlacement.Wrap(err))
+		}
+		if storj.PlacementConstraint(id) == storj.EveryCountry && !options.allowEveryCountryOverride {
+			return withLocation(ErrPlacement.New("placement %d is reserved for the unrestricted default placement, "+
+				"it can be redefined only with placement-allow-every-country-override", id))
+		}
+		if _, found := pending[storj.PlacementConstraint(id)]; found && !options.allowOverride {
+			return withLocation(ErrPlacement.New("placement %d is defined multiple times", id))
+		}
+		if existing, found := d[storj.PlacementConstraint(id)]; found && !existing.seeded && !options.allowOverride {
+			return withLocation(ErrPlacement.New("placement %d is already defined", id))
+		}
+		pending[storj.PlacementConstraint(id)] = pendingPlacement{
+			placementSource: source,
+			expression:      idDef[1],
+		}
+		order = append(order, storj.PlacementConstraint(id))
+	}
+
+	// second pass: evaluate the definitions, resolving the referenced placements first.
+	var resolving []storj.PlacementConstraint
+	var resolve func(id storj.PlacementConstraint) error
+
+	named := map[string]NodeFilter{}
+
 	env := map[any]any{
-		"country": func(countries ...string) (NodeFilter, error) {
-			return NewCountryFilterFromString(countries)
+		"ref": func(name string) (NodeFilter, error) {
+			filter, found := named[name]
+			if !found {
+				return nil, ErrPlacement.New("filter %q is referenced, but not defined", name)
+			}
+			return filter, nil
+		},
+		"country": countryFilter,
+		"continent": func(continent string) (NodeFilter, error) {
+			return NewContinentFilter(continent)
 		},
 		"placement": func(ix int64) (NodeFilter, error) {
+			if err := resolve(storj.PlacementConstraint(ix)); err != nil {
+				return nil, err
+			}
 			filter, found := d[storj.PlacementConstraint(ix)]
 			if !found {
-				return nil, ErrPlacement.New("Placement %d is referenced before defined. Please define it first!", ix)
+				return nil, ErrPlacement.New("Placement %d is referenced, but not defined", ix)
 			}
 			return filter.NodeFilter, nil
 		},
@@ -237,32 +726,42 @@ func (d PlacementDefinitions) AddPlacementFromString(definitions string) error {
 			}
 			return OrFilter{filter1, filter2}, nil
 		},
+		// not is a built-in operator of mito (like !), not a function.
+		mito.ModNot: notFilterOp,
 		"tag": func(nodeIDstr string, key string, value any) (NodeFilters, error) {
 			nodeID, err := storj.NodeIDFromString(nodeIDstr)
 			if err != nil {
 				return nil, err
 			}
 
-			var rawValue []byte
-			match := bytes.Equal
-			switch v := value.(type) {
-			case string:
-				rawValue = []byte(v)
-			case []byte:
-				rawValue = v
-			case stringNotMatch:
-				match = func(a, b []byte) bool {
-					return !bytes.Equal(a, b)
-				}
-				rawValue = []byte(v)
-			default:
-				return nil, ErrPlacement.New("3rd argument of tag() should be string or []byte")
+			rawValue, match, valueExpr, err := tagValueMatch(value)
+			if err != nil {
+				return nil, err
 			}
+			filter := NewTagFilter(nodeID, key, rawValue, match)
+			filter.valueExpr = valueExpr
 			res := NodeFilters{
-				NewTagFilter(nodeID, key, rawValue, match),
+				filter,
 			}
 			return res, nil
 		},
+		"anytag": func(key string, value any) (NodeFilters, error) {
+			rawValue, match, valueExpr, err := tagValueMatch(value)
+			if err != nil {
+				return nil, err
+			}
+			filter := NewAnySignerTagFilter(key, rawValue, match)
+			filter.valueExpr = valueExpr
+			return NodeFilters{
+				filter,
+			}, nil
+		},
+		"tagprefix": func(nodeIDstr string, key string, prefix string) (NodeFilters, error) {
+			return tagAffixFilter("tagprefix", nodeIDstr, key, prefix, bytes.HasPrefix)
+		},
+		"tagsuffix": func(nodeIDstr string, key string, suffix string) (NodeFilters, error) {
+			return tagAffixFilter("tagsuffix", nodeIDstr, key, suffix, bytes.HasSuffix)
+		},
 		"annotated": func(filter NodeFilter, kv ...Annotation) (AnnotatedNodeFilter, error) {
 			return AnnotatedNodeFilter{
 				Filter:      filter,
@@ -278,32 +777,65 @@ func (d PlacementDefinitions) AddPlacementFromString(definitions string) error {
 		"exclude": func(filter NodeFilter) (NodeFilter, error) {
 			return NewExcludeFilter(filter), nil
 		},
+		"atLeast": func(threshold int64, filters ...NodeFilter) (NodeFilter, error) {
+			return NewThresholdFilter(int(threshold), filters...)
+		},
+		"version": func(constraint string) (NodeFilter, error) {
+			return NewVersionFilter(constraint)
+		},
+		"lastContact": func(maxAge string) (NodeFilter, error) {
+			return NewLastContactFilter(maxAge)
+		},
+		"minFreeDisk": func(size string) (NodeFilter, error) {
+			return NewFreeDiskFilter(size)
+		},
+		"spread": spreadFilter,
+		"vetted": func(vetted bool) (NodeFilter, error) {
+			return NewVettedFilter(vetted), nil
+		},
+		// boolean values, like vetted(false).
+		"true":  true,
+		"false": false,
 		"empty": func() string {
 			return ""
 		},
 		"notEmpty": func() any {
 			return stringNotMatch("")
 		},
+		"gt":  tagGreaterThan,
+		"gte": tagGreaterOrEqual,
+		"lt":  tagLessThan,
+		"lte": tagLessOrEqual,
 	}
 
-	for _, definition := range strings.Split(definitions, ";") {
-		definition = strings.TrimSpace(definition)
-		if definition == "" {
-			continue
+	resolve = func(id storj.PlacementConstraint) error {
+		p, found := pending[id]
+		if !found {
+			// already resolved, or defined outside of these definitions.
+			return nil
 		}
-		idDef := strings.SplitN(definition, ":", 2)
 
-		if len(idDef) != 2 {
-			return ErrPlacement.New("placement definition should be in the form ID:definition (but it was %s)", definition)
-		}
-		val, err := mito.Eval(idDef[1], env)
-		if err != nil {
-			return ErrPlacement.New("Error in line '%s' when placement rule is parsed: %v", idDef[1], err)
+		for i, resolvingID := range resolving {
+			if resolvingID == id {
+				var cycle []string
+				for _, cycleID := range append(resolving[i:], id) {
+					cycle = append(cycle, strconv.Itoa(int(cycleID)))
+				}
+				return ErrPlacement.New("placement references form a cycle: %s", strings.Join(cycle, " -> "))
+			}
 		}
-		id, err := strconv.Atoi(idDef[0])
+		resolving = append(resolving, id)
+		defer func() { resolving = resolving[:len(resolving)-1] }()
+
+		val, err := mito.Eval(p.expression, env)
 		if err != nil {
-			return ErrPlacement.Wrap(err)
+			err = ErrPlacement.New("Error in placement definition '%s': %v (supported functions: %s)", p.definition, err, strings.Join(envFunctionNames(env), ", "))
+			if p.location != "" {
+				err = ErrPlacement.New("%s: %v", p.location, errs.Unwrap(err))
+			}
+			return err
 		}
+
 		placement := Placement{
 			NodeFilter: val.(NodeFilter),
 		}
@@ -311,17 +843,72 @@ func (d PlacementDefinitions) AddPlacementFromString(definitions string) error {
 		if GetAnnotation(placement.NodeFilter, AutoExcludeSubnet) != AutoExcludeSubnetOFF {
 			placement.Selector = AttributeGroupSelector(LastNetAttribute)
 			placement.Invariant = ClumpingByAttribute(LastNetAttribute, 1)
+			placement.ExcludeSameSubnet = true
 		} else {
 			placement.Selector = RandomSelector()
 		}
 
 		placement.Name = GetAnnotation(placement.NodeFilter, Location)
 
-		d[storj.PlacementConstraint(id)] = placement
+		d[id] = placement
+		delete(pending, id)
+		return nil
+	}
+
+	defineEnv := map[any]any{
+		"define": func(name string, filter NodeFilter) (NodeFilter, error) {
+			if _, found := named[name]; found {
+				return nil, ErrPlacement.New("filter %q is defined multiple times", name)
+			}
+			named[name] = filter
+			return filter, nil
+		},
+	}
+	for key, value := range env {
+		defineEnv[key] = value
+	}
+
+	for _, source := range defines {
+		if _, err := mito.Eval(source.definition, defineEnv); err != nil {
+			err = ErrPlacement.New("Error in filter definition '%s': %v (supported functions: %s)", source.definition, err, strings.Join(envFunctionNames(defineEnv), ", "))
+			if source.location != "" {
+				err = ErrPlacement.New("%s: %v", source.location, errs.Unwrap(err))
+			}
+			return err
+		}
+	}
+
+	for _, id := range order {
+		if err := resolve(id); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// notFilterOp implements the not (and !) operator of the placement DSL, which negates NodeFilter instances.
+func notFilterOp(env map[any]any, a any) (any, error) {
+	switch value := a.(type) {
+	case NodeFilter:
+		return NewNotFilter(value), nil
+	case bool:
+		return !value, nil
+	default:
+		return nil, ErrPlacement.New("not is supported only for NodeFilter instances")
+	}
+}
+
+// envFunctionNames returns the sorted names of the functions available in a placement DSL environment.
+func envFunctionNames(env map[any]any) (names []string) {
+	for key, value := range env {
+		if name, ok := key.(string); ok && reflect.ValueOf(value).Kind() == reflect.Func {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // CreateFilters implements PlacementCondition.
 func (d PlacementDefinitions) CreateFilters(constraint storj.PlacementConstraint) (filter NodeFilter) {
 	if filters, found := d[constraint]; found {
@@ -332,10 +919,103 @@ func (d PlacementDefinitions) CreateFilters(constraint storj.PlacementConstraint
 	}
 }
 
-// SupportedPlacements returns all the IDs, which have associated placement rules.
+// CreateFiltersWithError returns the filter of the placement, or ErrUnknownPlacement if the placement is not defined.
+func (d PlacementDefinitions) CreateFiltersWithError(constraint storj.PlacementConstraint) (filter NodeFilter, err error) {
+	if filters, found := d[constraint]; found {
+		return filters.NodeFilter, nil
+	}
+	return nil, ErrUnknownPlacement.New("placement %d has no placement rules", constraint)
+}
+
+// Explain returns whether the node is matched by the placement, and a human-readable reason
+// when it isn't. It's a debugging aid, node selection uses the filters directly.
+func (d PlacementDefinitions) Explain(id storj.PlacementConstraint, node *SelectedNode) (matched bool, reason string, err error) {
+	filter, err := d.CreateFiltersWithError(id)
+	if err != nil {
+		return false, "", err
+	}
+	matched, reason = MatchExplain(filter, node)
+	return matched, reason, nil
+}
+
+// PlacementConstraints returns the set constraints of the placement, which should be checked on the
+// selected nodes. Unknown placements don't have set constraints.
+func (d PlacementDefinitions) PlacementConstraints(id storj.PlacementConstraint) []SetConstraint {
+	placement, found := d[id]
+	if !found {
+		return nil
+	}
+	return SetConstraints(placement.NodeFilter)
+}
+
+// AutoExcludeSubnet returns true if the placement doesn't allow multiple nodes from the same subnet
+// (see Placement.ExcludeSameSubnet). Subnet diversity is enforced for unknown placements.
+func (d PlacementDefinitions) AutoExcludeSubnet(id storj.PlacementConstraint) bool {
+	placement, found := d[id]
+	if !found {
+		return true
+	}
+	return placement.ExcludeSameSubnet
+}
+
+// SupportedPlacements returns all the IDs, which have associated placement rules, in ascending order.
 func (d PlacementDefinitions) SupportedPlacements() (res []storj.PlacementConstraint) {
 	for id := range d {
 		res = append(res, id)
 	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
 	return res
 }
+
+// HasPlacement returns true if the placement has associated placement rules.
+func (d PlacementDefinitions) HasPlacement(id storj.PlacementConstraint) bool {
+	_, found := d[id]
+	return found
+}
+
+// ReloadablePlacementDefinitions holds placement definitions which can be replaced at runtime
+// (eg. when the configuration is reloaded) while they are used concurrently.
+// The held PlacementDefinitions shouldn't be modified after they are stored.
+// The zero value has no placement definitions.
+type ReloadablePlacementDefinitions struct {
+	current atomic.Pointer[PlacementDefinitions]
+}
+
+// NewReloadablePlacementDefinitions creates ReloadablePlacementDefinitions with the initial definitions.
+func NewReloadablePlacementDefinitions(definitions PlacementDefinitions) *ReloadablePlacementDefinitions {
+	r := &ReloadablePlacementDefinitions{}
+	r.Reload(definitions)
+	return r
+}
+
+// Reload replaces the placement definitions.
+func (r *ReloadablePlacementDefinitions) Reload(definitions PlacementDefinitions) {
+	r.current.Store(&definitions)
+}
+
+// Load returns the current placement definitions.
+func (r *ReloadablePlacementDefinitions) Load() PlacementDefinitions {
+	current := r.current.Load()
+	if current == nil {
+		return PlacementDefinitions{}
+	}
+	return *current
+}
+
+// CreateFilters implements PlacementRules with the current placement definitions.
+func (r *ReloadablePlacementDefinitions) CreateFilters(constraint storj.PlacementConstraint) (filter NodeFilter) {
+	return r.Load().CreateFilters(constraint)
+}
+
+// CreateFiltersWithError creates the filter of the placement with the current placement definitions.
+func (r *ReloadablePlacementDefinitions) CreateFiltersWithError(constraint storj.PlacementConstraint) (NodeFilter, error) {
+	return r.Load().CreateFiltersWithError(constraint)
+}
+
+// AutoExcludeSubnet returns true if the placement doesn't allow multiple nodes from the same subnet
+// with the current placement definitions.
+func (r *ReloadablePlacementDefinitions) AutoExcludeSubnet(id storj.PlacementConstraint) bool {
+	return r.Load().AutoExcludeSubnet(id)
+}
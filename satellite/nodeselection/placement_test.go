@@ -4,14 +4,23 @@
 package nodeselection
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"storj.io/common/identity/testidentity"
+	"storj.io/common/memory"
 	"storj.io/common/storj"
 	"storj.io/common/storj/location"
+	"storj.io/common/version"
 )
 
 func TestPlacementFromString(t *testing.T) {
@@ -174,11 +183,43 @@ func TestPlacementFromString(t *testing.T) {
 		}))
 	})
 
-	t.Run("placement reuse wrong", func(t *testing.T) {
+	t.Run("placement forward reference", func(t *testing.T) {
 		p := TestPlacementDefinitions()
 		err := p.AddPlacementFromString(`1:exclude(placement(2));2:country("DE")`)
+		require.NoError(t, err)
+		require.False(t, p[1].Match(&SelectedNode{
+			CountryCode: location.Germany,
+		}))
+		require.True(t, p[1].Match(&SelectedNode{
+			CountryCode: location.UnitedKingdom,
+		}))
+		require.True(t, p[2].Match(&SelectedNode{
+			CountryCode: location.Germany,
+		}))
+	})
+
+	t.Run("placement reference undefined", func(t *testing.T) {
+		p := TestPlacementDefinitions()
+		err := p.AddPlacementFromString(`1:exclude(placement(7));2:country("DE")`)
 		require.True(t, ErrPlacement.Has(err))
-		require.ErrorContains(t, err, "referenced before defined")
+		require.ErrorContains(t, err, "Placement 7 is referenced, but not defined")
+	})
+
+	t.Run("placement reference cycle", func(t *testing.T) {
+		p := TestPlacementDefinitions()
+		err := p.AddPlacementFromString(`10:placement(11);11:placement(10)`)
+		require.True(t, ErrPlacement.Has(err))
+		require.ErrorContains(t, err, "10 -> 11 -> 10")
+
+		p = TestPlacementDefinitions()
+		err = p.AddPlacementFromString(`10:placement(10)`)
+		require.True(t, ErrPlacement.Has(err))
+		require.ErrorContains(t, err, "10 -> 10")
+
+		p = TestPlacementDefinitions()
+		err = p.AddPlacementFromString(`10:country("DE");11:placement(12) && country("GB");12:placement(10) || placement(11)`)
+		require.True(t, ErrPlacement.Has(err))
+		require.ErrorContains(t, err, "11 -> 12 -> 11")
 	})
 
 	t.Run("all rules", func(t *testing.T) {
@@ -364,6 +405,29 @@ func TestPlacementFromString(t *testing.T) {
 		}))
 	})
 
+	t.Run("exclude composite", func(t *testing.T) {
+		p := TestPlacementDefinitions()
+		err := p.AddPlacementFromString(`11:exclude(country("DE") && tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo","bar"))`)
+		require.NoError(t, err)
+		filters := p[storj.PlacementConstraint(11)]
+
+		signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+		require.NoError(t, err)
+		tagged := NodeTags{{Signer: signer, Name: "foo", Value: []byte("bar")}}
+
+		require.False(t, filters.Match(&SelectedNode{
+			CountryCode: location.Germany,
+			Tags:        tagged,
+		}))
+		require.True(t, filters.Match(&SelectedNode{
+			CountryCode: location.Germany,
+		}))
+		require.True(t, filters.Match(&SelectedNode{
+			CountryCode: location.UnitedKingdom,
+			Tags:        tagged,
+		}))
+	})
+
 	t.Run("legacy geofencing rules", func(t *testing.T) {
 		p := TestPlacementDefinitions()
 		p.AddLegacyStaticRules()
@@ -399,7 +463,7 @@ func TestPlacementFromString(t *testing.T) {
 		// this is a realistic configuration, compatible with legacy rules + using one node tag for specific placement
 
 		rules1 := TestPlacementDefinitions()
-		err := rules1.AddPlacementFromString(`
+		err := rules1.addPlacementFromString(`
 						10:tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","selected",notEmpty());
 						13:tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","datacenter","true");
 						11:placement(10) && annotation("autoExcludeSubnet","off") && annotation("location","do-not-use");
@@ -410,7 +474,8 @@ func TestPlacementFromString(t *testing.T) {
 						3:country("US") && exclude(placement(10)) && exclude(placement(13)) && annotation("location","us-1");
 						4:country("DE") && exclude(placement(10)) && exclude(placement(13)) && annotation("location","de-1");
 						6:country("*","!BY", "!RU", "!NONE") && exclude(placement(10)) && exclude(placement(13)) && annotation("location","custom-1");
-						14:placement(13) && annotation("autoExcludeSubnet","off") && annotation("location","global-datacenter");`)
+						14:placement(13) && annotation("autoExcludeSubnet","off") && annotation("location","global-datacenter");`,
+			placementOptions{allowEveryCountryOverride: true})
 		require.NoError(t, err)
 
 		// for countries, it should be the same as above
@@ -547,3 +612,938 @@ func TestStringSerialization(t *testing.T) {
 
 	}
 }
+
+func TestFilterStringRoundTrip(t *testing.T) {
+	for _, definition := range []string{
+		`all()`,
+		`country("GB")`,
+		`country("*","!RU","!BY")`,
+		`exclude(country("DE"))`,
+		`not(country("DE"))`,
+		`country("GB") || country("DE")`,
+		`country("GB") && tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo","bar")`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo",notEmpty())`,
+		`anytag("foo",notEmpty())`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",gt(500))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",gte(500))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",lt(500))`,
+		`anytag("free_disk",lte(100))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","port",28967)`,
+		`tagsuffix("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","email","@storjshare.io")`,
+		`tagprefix("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","wallet","0x")`,
+		`atLeast(2,country("DE"),country("GB"),country("US"))`,
+		`version(">=1.95.0")`,
+		`version("<1.100.0-rc")`,
+		`lastContact("2h0m0s")`,
+		`minFreeDisk("10000000000B")`,
+		`country("DE","FR","PL") && spread(country,2)`,
+		`vetted(true)`,
+		`country("DE") && vetted(false)`,
+		`annotated(country("DE"),annotation("location","de"),annotation("autoExcludeSubnet","off"))`,
+		`annotated(exclude(country("DE") || country("GB")),annotation("location","no-de-gb"))`,
+	} {
+		definition := definition
+		t.Run(definition, func(t *testing.T) {
+			parsed := PlacementDefinitions{}
+			require.NoError(t, parsed.AddPlacementFromString("1:"+definition))
+			serialized := fmt.Sprintf("%s", parsed[1].NodeFilter)
+
+			reparsed := PlacementDefinitions{}
+			require.NoError(t, reparsed.AddPlacementFromString("1:"+serialized))
+			require.Equal(t, serialized, fmt.Sprintf("%s", reparsed[1].NodeFilter))
+			require.Equal(t, parsed[1].Name, reparsed[1].Name)
+		})
+	}
+
+	require.Equal(t, "exclude(all())", ExcludeAllFilter{}.String())
+	require.Equal(t, "all()", AnyFilter{}.String())
+
+	for _, tagDefinition := range []string{
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo",notEmpty())`,
+		`anytag("foo",notEmpty())`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",gt(500))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",gte(500))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","free_disk",lt(500))`,
+		`anytag("free_disk",lte(100))`,
+		`tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","port",28967)`,
+		`tagsuffix("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","email","@storjshare.io")`,
+		`tagprefix("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","wallet","0x")`,
+	} {
+		parsed := PlacementDefinitions{}
+		require.NoError(t, parsed.AddPlacementFromString("1:"+tagDefinition))
+		require.Equal(t, tagDefinition, fmt.Sprintf("%s", parsed[1].NodeFilter))
+	}
+}
+
+func TestPlacementExplain(t *testing.T) {
+	signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+	require.NoError(t, err)
+
+	rule := ConfigurablePlacementRule{
+		PlacementRules: `10:country("DE") && tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","tier","gold");` +
+			`11:annotated(exclude(country("GB")),annotation("location","no-gb"));` +
+			`12:lastContact("1h")`,
+	}
+
+	explain := func(id storj.PlacementConstraint, node *SelectedNode) (bool, string) {
+		matched, reason, err := rule.Explain(nil, id, node)
+		require.NoError(t, err)
+		return matched, reason
+	}
+
+	matched, reason := explain(10, &SelectedNode{
+		CountryCode: location.Germany,
+		Tags:        NodeTags{{Signer: signer, Name: "tier", Value: []byte("gold")}},
+	})
+	require.True(t, matched)
+	require.Empty(t, reason)
+
+	_, reason = explain(10, &SelectedNode{CountryCode: location.UnitedKingdom})
+	require.Equal(t, `country "GB" of the node is not permitted by country("DE")`, reason)
+
+	_, reason = explain(10, &SelectedNode{CountryCode: location.Germany})
+	require.Contains(t, reason, `node has no "tier" tag signed by 12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4`)
+
+	_, reason = explain(10, &SelectedNode{
+		CountryCode: location.Germany,
+		Tags:        NodeTags{{Signer: signer, Name: "tier", Value: []byte("silver")}},
+	})
+	require.Contains(t, reason, `value "silver" of the "tier" tag is not matched by`)
+
+	_, reason = explain(11, &SelectedNode{CountryCode: location.UnitedKingdom})
+	require.Equal(t, `node is excluded by exclude(country("GB"))`, reason)
+
+	// filters without explanation are reported with their definition.
+	_, reason = explain(12, &SelectedNode{})
+	require.Equal(t, `node is not matched by lastContact("1h0m0s")`, reason)
+
+	_, _, err = rule.Explain(nil, 13, &SelectedNode{})
+	require.True(t, ErrUnknownPlacement.Has(err))
+}
+
+func TestEveryCountryPlacementIsProtected(t *testing.T) {
+	p := TestPlacementDefinitions()
+	err := p.AddPlacementFromString(`0:country("DE")`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "placement-allow-every-country-override")
+	require.True(t, p[storj.EveryCountry].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+
+	rule := ConfigurablePlacementRule{
+		PlacementRules: `10:country("GB");0:country("DE")`,
+	}
+	_, err = rule.Parse(nil)
+	require.Error(t, err)
+
+	rule.AllowEveryCountryOverride = true
+	defs, err := rule.Parse(nil)
+	require.NoError(t, err)
+	require.True(t, defs[storj.EveryCountry].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+	require.False(t, defs[storj.EveryCountry].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+}
+
+func TestConfigurablePlacementRuleJSON(t *testing.T) {
+	rule := ConfigurablePlacementRule{
+		PlacementRules:            `11:annotated(country("DE"),annotation("location","de"),annotation("autoExcludeSubnet","off"));10:country("GB") || country("US");0:exclude(country("RU"))`,
+		AllowEveryCountryOverride: true,
+	}
+
+	data, err := json.Marshal(rule)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"id":0,"definition":"exclude(country(\"RU\"))"},
+		{"id":10,"definition":"(country(\"GB\") || country(\"US\"))"},
+		{"id":11,"definition":"country(\"DE\")","annotations":{"location":"de","autoExcludeSubnet":"off"}}
+	]`, string(data))
+
+	var protected ConfigurablePlacementRule
+	require.Error(t, json.Unmarshal(data, &protected))
+
+	decoded := ConfigurablePlacementRule{AllowEveryCountryOverride: true}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	definitions, err := decoded.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "de", definitions[11].Name)
+	require.Equal(t, AutoExcludeSubnetOFF, GetAnnotation(definitions[11].NodeFilter, AutoExcludeSubnet))
+	require.True(t, definitions[10].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+	require.False(t, definitions[0].NodeFilter.Match(&SelectedNode{CountryCode: location.Russia}))
+
+	// the decoded rules have the same representation.
+	reencoded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(reencoded))
+
+	// empty rules.
+	data, err = json.Marshal(ConfigurablePlacementRule{})
+	require.NoError(t, err)
+	require.Equal(t, `[]`, string(data))
+
+	// invalid definitions are rejected.
+	err = json.Unmarshal([]byte(`[{"id":10,"definition":"contry(\"DE\")"}]`), &decoded)
+	require.Error(t, err)
+	require.True(t, ErrPlacement.Has(err))
+}
+
+func TestContinentPlacement(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`11:continent("africa");12:continent("europe") && exclude(country("DE"))`)
+	require.NoError(t, err)
+
+	require.True(t, p[11].NodeFilter.Match(&SelectedNode{CountryCode: location.ToCountryCode("EG")}))
+	require.False(t, p[11].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+	require.True(t, p[12].NodeFilter.Match(&SelectedNode{CountryCode: location.France}))
+	require.False(t, p[12].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+
+	err = p.AddPlacementFromString(`13:continent("atlantis")`)
+	require.Error(t, err)
+}
+
+func TestNotPlacement(t *testing.T) {
+	signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+	require.NoError(t, err)
+
+	p := PlacementDefinitions{}
+	err = p.AddPlacementFromString(fmt.Sprintf(`11:not(tag("%s","k","v") && country("EU"));12:all(not(country("DE")),country("EU"));13:not(annotated(country("DE"),annotation("location","not-de")))`, signer))
+	require.NoError(t, err)
+
+	taggedDE := &SelectedNode{
+		CountryCode: location.Germany,
+		Tags: NodeTags{
+			{Signer: signer, Name: "k", Value: []byte("v")},
+		},
+	}
+	untaggedDE := &SelectedNode{CountryCode: location.Germany}
+	fr := &SelectedNode{CountryCode: location.France}
+	us := &SelectedNode{CountryCode: location.UnitedStates}
+
+	require.False(t, p[11].NodeFilter.Match(taggedDE))
+	require.True(t, p[11].NodeFilter.Match(untaggedDE))
+	require.True(t, p[11].NodeFilter.Match(us))
+
+	require.False(t, p[12].NodeFilter.Match(untaggedDE))
+	require.True(t, p[12].NodeFilter.Match(fr))
+	require.False(t, p[12].NodeFilter.Match(us))
+
+	require.False(t, p[13].NodeFilter.Match(untaggedDE))
+	require.True(t, p[13].NodeFilter.Match(us))
+	require.Equal(t, "not-de", p[13].Name)
+
+	filter, err := FilterFromString(`not(country("DE")) && continent("europe")`)
+	require.NoError(t, err)
+	require.False(t, filter.Match(untaggedDE))
+	require.True(t, filter.Match(fr))
+	require.False(t, filter.Match(us))
+
+	_, err = FilterFromString(`not("DE")`)
+	require.Error(t, err)
+}
+
+func TestThresholdPlacement(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`10:atLeast(2,country("DE","GB"),continent("europe"),exclude(country("GB")));11:atLeast(1,country("DE"),country("GB"))`)
+	require.NoError(t, err)
+
+	de := &SelectedNode{CountryCode: location.Germany}
+	gb := &SelectedNode{CountryCode: location.UnitedKingdom}
+	fr := &SelectedNode{CountryCode: location.France}
+	us := &SelectedNode{CountryCode: location.UnitedStates}
+
+	// 2 of 3
+	require.True(t, p[10].NodeFilter.Match(de))
+	require.True(t, p[10].NodeFilter.Match(gb))
+	require.True(t, p[10].NodeFilter.Match(fr))
+	require.False(t, p[10].NodeFilter.Match(us))
+
+	// 1 of 2
+	require.True(t, p[11].NodeFilter.Match(de))
+	require.True(t, p[11].NodeFilter.Match(gb))
+	require.False(t, p[11].NodeFilter.Match(fr))
+
+	for _, definition := range []string{
+		`atLeast(0,country("DE"),country("GB"))`,
+		`atLeast(3,country("DE"),country("GB"))`,
+		`atLeast(1)`,
+	} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString("10:" + definition)
+		require.Error(t, err, definition)
+		require.Contains(t, err.Error(), "threshold of atLeast()")
+	}
+
+	filter, err := FilterFromString(`atLeast(1,country("DE"),country("GB"))`)
+	require.NoError(t, err)
+	require.True(t, filter.Match(gb))
+	require.False(t, filter.Match(us))
+}
+
+func TestVersionPlacement(t *testing.T) {
+	withVersion := func(ver string) *SelectedNode {
+		semVer, err := version.NewSemVer(ver)
+		require.NoError(t, err)
+		return &SelectedNode{Version: semVer}
+	}
+
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`10:version(">=1.95.0");11:version("<1.95.0");12:version("1.95.1");13:version("!= v1.95.0") && country("DE")`)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		version  string
+		expected map[storj.PlacementConstraint]bool
+	}{
+		{"v1.94.2", map[storj.PlacementConstraint]bool{10: false, 11: true, 12: false}},
+		{"v1.95.0", map[storj.PlacementConstraint]bool{10: true, 11: false, 12: false}},
+		{"v1.95.1", map[storj.PlacementConstraint]bool{10: true, 11: false, 12: true}},
+		{"v1.100.0", map[storj.PlacementConstraint]bool{10: true, 11: false, 12: false}},
+	} {
+		for placement, expected := range tc.expected {
+			require.Equal(t, expected, p[placement].NodeFilter.Match(withVersion(tc.version)), "version %s, placement %d", tc.version, placement)
+		}
+	}
+
+	// nodes without reported version don't match minimum versions.
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{}))
+
+	node := withVersion("v1.95.2")
+	node.CountryCode = location.Germany
+	require.True(t, p[13].NodeFilter.Match(node))
+
+	for _, constraint := range []string{`>=1.x`, `>=`, `~1.95.0`, ``} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString(fmt.Sprintf(`10:version("%s")`, constraint))
+		require.Error(t, err, constraint)
+		require.Contains(t, err.Error(), "invalid version constraint", constraint)
+	}
+}
+
+func TestLastContactPlacement(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`10:lastContact("2h");11:lastContact("30m") && country("DE")`)
+	require.NoError(t, err)
+
+	contacted := func(ago time.Duration) *SelectedNode {
+		return &SelectedNode{
+			CountryCode:        location.Germany,
+			LastContactSuccess: time.Now().Add(-ago),
+		}
+	}
+
+	require.True(t, p[10].NodeFilter.Match(contacted(time.Minute)))
+	require.True(t, p[10].NodeFilter.Match(contacted(time.Hour)))
+	require.False(t, p[10].NodeFilter.Match(contacted(3*time.Hour)))
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{}))
+
+	require.True(t, p[11].NodeFilter.Match(contacted(time.Minute)))
+	require.False(t, p[11].NodeFilter.Match(contacted(time.Hour)))
+
+	for _, duration := range []string{`2 hours`, ``, `-1h`, `0s`} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString(fmt.Sprintf(`10:lastContact("%s")`, duration))
+		require.Error(t, err, duration)
+		require.Contains(t, err.Error(), "lastContact()", duration)
+	}
+}
+
+func TestFreeDiskPlacement(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`10:minFreeDisk("10GB");11:minFreeDisk("1 TiB") && country("DE")`)
+	require.NoError(t, err)
+
+	withFreeDisk := func(freeDisk memory.Size) *SelectedNode {
+		return &SelectedNode{
+			CountryCode: location.Germany,
+			FreeDisk:    freeDisk.Int64(),
+		}
+	}
+
+	require.True(t, p[10].NodeFilter.Match(withFreeDisk(10*memory.GB)))
+	require.True(t, p[10].NodeFilter.Match(withFreeDisk(memory.TiB)))
+	require.False(t, p[10].NodeFilter.Match(withFreeDisk(10*memory.GB-1)))
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{}))
+
+	require.True(t, p[11].NodeFilter.Match(withFreeDisk(memory.TiB)))
+	require.False(t, p[11].NodeFilter.Match(withFreeDisk(memory.TB)))
+
+	for _, size := range []string{`10 apples`, ``, `GB`, `-1GB`, `0`} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString(fmt.Sprintf(`10:minFreeDisk("%s")`, size))
+		require.Error(t, err, size)
+		require.Contains(t, err.Error(), "minFreeDisk()", size)
+	}
+}
+
+func TestNamedFilters(t *testing.T) {
+	de := &SelectedNode{CountryCode: location.Germany}
+	gb := &SelectedNode{CountryCode: location.UnitedKingdom}
+	us := &SelectedNode{CountryCode: location.UnitedStates}
+
+	t.Run("valid", func(t *testing.T) {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString(`define("tier1",country("US","DE","GB"));10:ref("tier1");11:ref("tier1") && exclude(country("DE"));12:ref("tier1-eu");define("tier1-eu",ref("tier1") && continent("europe"))`)
+		require.NoError(t, err)
+
+		require.True(t, p[10].NodeFilter.Match(us))
+		require.True(t, p[10].NodeFilter.Match(de))
+
+		require.True(t, p[11].NodeFilter.Match(us))
+		require.False(t, p[11].NodeFilter.Match(de))
+
+		require.False(t, p[12].NodeFilter.Match(us))
+		require.True(t, p[12].NodeFilter.Match(gb))
+
+		// named filters are not placements.
+		require.Len(t, p, 3)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "placement.txt")
+		require.NoError(t, os.WriteFile(path, []byte(`
+define("tier1",
+	country("US","DE","GB"))
+10:ref("tier1")
+`), 0644))
+
+		p := PlacementDefinitions{}
+		require.NoError(t, p.AddPlacementFromFile(path))
+		require.True(t, p[10].NodeFilter.Match(gb))
+		require.False(t, p[10].NodeFilter.Match(&SelectedNode{CountryCode: location.France}))
+	})
+
+	for _, tc := range []struct {
+		definitions string
+		expected    string
+	}{
+		{`10:ref("tier1")`, `filter "tier1" is referenced, but not defined`},
+		{`define("eu",ref("tier1"));define("tier1",country("DE"));10:ref("eu")`, `filter "tier1" is referenced, but not defined`},
+		{`define("tier1",country("DE"));define("tier1",country("GB"))`, `filter "tier1" is defined multiple times`},
+	} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString(tc.definitions)
+		require.Error(t, err, tc.definitions)
+		require.Contains(t, err.Error(), tc.expected, tc.definitions)
+	}
+}
+
+func TestDuplicatedPlacement(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`12:country("DE");12:country("US")`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "12")
+
+	// already defined placements can't be redefined by a later call
+	p = PlacementDefinitions{}
+	require.NoError(t, p.AddPlacementFromString(`12:country("DE")`))
+	err = p.AddPlacementFromString(`12:country("US")`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "placement 12 is already defined")
+	require.True(t, p[12].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+
+	// seeded placements can be redefined, but only once
+	p = TestPlacementDefinitions()
+	p.AddLegacyStaticRules()
+	require.NoError(t, p.addPlacementFromString(`0:country("DE");1:country("US")`, placementOptions{allowEveryCountryOverride: true}))
+	require.True(t, p[1].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+	require.Error(t, p.AddPlacementFromString(`1:country("GB")`))
+
+	// legacy static rules can be redefined
+	rule := ConfigurablePlacementRule{
+		PlacementRules: `1:country("DE")`,
+	}
+	defs, err := rule.Parse(nil)
+	require.NoError(t, err)
+	require.True(t, defs[1].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+
+	rule = ConfigurablePlacementRule{
+		PlacementRules: `12:country("DE");12:country("US")`,
+	}
+	_, err = rule.Parse(nil)
+	require.Error(t, err)
+
+	rule.AllowOverride = true
+	defs, err = rule.Parse(nil)
+	require.NoError(t, err)
+	require.True(t, defs[12].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+	require.False(t, defs[12].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+}
+
+func TestMultilinePlacementFromString(t *testing.T) {
+	signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+	require.NoError(t, err)
+
+	p := PlacementDefinitions{}
+	err = p.AddPlacementFromString(fmt.Sprintf(`
+		10:country(
+			"DE",
+			"US"
+		);
+		11 :
+			country("GB")
+			&&
+			tag("%s", "owner", "storj  labs")
+	`, signer))
+	require.NoError(t, err)
+
+	require.True(t, p[10].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+	require.True(t, p[10].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+
+	// whitespace in the string literals is kept.
+	taggedWith := func(value string) *SelectedNode {
+		return &SelectedNode{
+			CountryCode: location.UnitedKingdom,
+			Tags: NodeTags{
+				{Signer: signer, Name: "owner", Value: []byte(value)},
+			},
+		}
+	}
+	require.True(t, p[11].NodeFilter.Match(taggedWith("storj  labs")))
+	require.False(t, p[11].NodeFilter.Match(taggedWith("storj labs")))
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	for _, tc := range []struct {
+		definition string
+		expected   string
+	}{
+		{"", ""},
+		{" \n\t ", ""},
+		{`country("DE")`, `country("DE")`},
+		{"country(\n\t\"DE\",\n\t\"US\"\n)", `country( "DE", "US" )`},
+		{"tag(\"id\",  \"key\", \"a \n b\")", "tag(\"id\", \"key\", \"a \n b\")"},
+		{`tag("id", "key", "a \"  quoted\"  value")`, `tag("id", "key", "a \"  quoted\"  value")`},
+	} {
+		require.Equal(t, tc.expected, normalizeWhitespace(tc.definition), tc.definition)
+	}
+}
+
+func TestPlacementFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid", func(t *testing.T) {
+		path := filepath.Join(dir, "placement.txt")
+		require.NoError(t, os.WriteFile(path, []byte(`
+# geofencing rules
+10:country("DE")
+
+11:country("US");12:country("GB")
+# continued definition
+13:country("DE") &&
+	exclude(country("GB"))
+`), 0644))
+
+		p := PlacementDefinitions{}
+		require.NoError(t, p.AddPlacementFromFile(path))
+		require.True(t, p[10].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+		require.True(t, p[11].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedStates}))
+		require.True(t, p[12].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+		require.True(t, p[13].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+		require.False(t, p[13].NodeFilter.Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+
+		rule := ConfigurablePlacementRule{PlacementRules: path}
+		parsed, err := rule.Parse(nil)
+		require.NoError(t, err)
+		require.True(t, parsed[10].NodeFilter.Match(&SelectedNode{CountryCode: location.Germany}))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.txt")
+		require.NoError(t, os.WriteFile(path, []byte(`# comment
+10:country("DE")
+
+11:contry("US")
+`), 0644))
+
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromFile(path)
+		require.Error(t, err)
+		require.Equal(t, 1, strings.Count(err.Error(), "placement: "), err.Error())
+		require.Contains(t, err.Error(), "placement: "+path+":4: Error in placement definition")
+	})
+
+	t.Run("duplicated", func(t *testing.T) {
+		path := filepath.Join(dir, "duplicated.txt")
+		require.NoError(t, os.WriteFile(path, []byte("10:country(\"DE\")\n10:country(\"US\")\n"), 0644))
+
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromFile(path)
+		require.Error(t, err)
+		require.Equal(t, "placement: "+path+":2: placement 10 is defined multiple times", err.Error())
+	})
+}
+
+func TestSupportedPlacements(t *testing.T) {
+	p := TestPlacementDefinitions()
+	require.NoError(t, p.AddPlacementFromString(`12:country("DE");3:country("US");10:country("GB")`))
+
+	require.Equal(t, []storj.PlacementConstraint{0, 3, 10, 12}, p.SupportedPlacements())
+	require.True(t, p.HasPlacement(0))
+	require.True(t, p.HasPlacement(10))
+	require.False(t, p.HasPlacement(11))
+}
+
+func TestPlacementName(t *testing.T) {
+	p := TestPlacementDefinitions()
+	require.NoError(t, p.AddPlacementFromString(`12:annotated(country("DE"), annotation("location", "Germany"));13:country("US")`))
+	p.AddPlacementWithName(14, "Great Britain", NewCountryFilter(location.NewSet(location.UnitedKingdom)))
+
+	name, found := p.PlacementName(12)
+	require.True(t, found)
+	require.Equal(t, "Germany", name)
+
+	name, found = p.PlacementName(14)
+	require.True(t, found)
+	require.Equal(t, "Great Britain", name)
+	require.True(t, p[14].Match(&SelectedNode{CountryCode: location.UnitedKingdom}))
+
+	_, found = p.PlacementName(13)
+	require.False(t, found)
+
+	_, found = p.PlacementName(15)
+	require.False(t, found)
+}
+
+func TestCreateFiltersWithError(t *testing.T) {
+	p := TestPlacementDefinitions()
+	require.NoError(t, p.AddPlacementFromString(`10:exclude(all())`))
+
+	filter, err := p.CreateFiltersWithError(10)
+	require.NoError(t, err)
+	require.False(t, filter.Match(&SelectedNode{CountryCode: location.Germany}))
+
+	_, err = p.CreateFiltersWithError(11)
+	require.Error(t, err)
+	require.True(t, ErrUnknownPlacement.Has(err))
+
+	// CreateFilters keeps excluding all the nodes for unknown placements.
+	require.False(t, p.CreateFilters(11).Match(&SelectedNode{CountryCode: location.Germany}))
+}
+
+func TestTagComparison(t *testing.T) {
+	signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+	require.NoError(t, err)
+
+	p := PlacementDefinitions{}
+	err = p.AddPlacementFromString(fmt.Sprintf(`10:tag("%[1]s","free_disk",gt(500));11:tag("%[1]s","free_disk",gte(500));12:tag("%[1]s","free_disk",lt(500));13:tag("%[1]s","free_disk",lte(500))`, signer))
+	require.NoError(t, err)
+
+	withFreeDisk := func(value string) *SelectedNode {
+		return &SelectedNode{
+			Tags: NodeTags{
+				{Signer: signer, Name: "free_disk", Value: []byte(value)},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		value    string
+		expected map[storj.PlacementConstraint]bool
+	}{
+		{"499", map[storj.PlacementConstraint]bool{10: false, 11: false, 12: true, 13: true}},
+		{"500", map[storj.PlacementConstraint]bool{10: false, 11: true, 12: false, 13: true}},
+		{"501", map[storj.PlacementConstraint]bool{10: true, 11: true, 12: false, 13: false}},
+		{"many", map[storj.PlacementConstraint]bool{10: false, 11: false, 12: false, 13: false}},
+	} {
+		for placement, expected := range tc.expected {
+			require.Equal(t, expected, p[placement].NodeFilter.Match(withFreeDisk(tc.value)), "value %s, placement %d", tc.value, placement)
+		}
+	}
+
+	filter, err := FilterFromString(fmt.Sprintf(`tag("%s","free_disk",gte(500))`, signer))
+	require.NoError(t, err)
+	require.True(t, filter.Match(withFreeDisk("500")))
+	require.False(t, filter.Match(withFreeDisk("100")))
+}
+
+func TestTagAffix(t *testing.T) {
+	signer1 := testidentity.MustPregeneratedSignedIdentity(0, storj.LatestIDVersion()).ID
+	signer2 := testidentity.MustPregeneratedSignedIdentity(1, storj.LatestIDVersion()).ID
+
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(fmt.Sprintf(`10:tagsuffix("%[1]s","email","@storjshare.io");11:tagprefix("%[1]s","email","operator@")`, signer1))
+	require.NoError(t, err)
+
+	taggedBy := func(signer storj.NodeID, value string) *SelectedNode {
+		return &SelectedNode{
+			Tags: NodeTags{
+				{Signer: signer, Name: "email", Value: []byte(value)},
+			},
+		}
+	}
+
+	require.True(t, p[10].NodeFilter.Match(taggedBy(signer1, "operator@storjshare.io")))
+	require.False(t, p[10].NodeFilter.Match(taggedBy(signer1, "operator@storjshare.io.example.com")))
+	require.False(t, p[10].NodeFilter.Match(taggedBy(signer2, "operator@storjshare.io")))
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{}))
+
+	require.True(t, p[11].NodeFilter.Match(taggedBy(signer1, "operator@storjshare.io")))
+	require.False(t, p[11].NodeFilter.Match(taggedBy(signer1, "admin@storjshare.io")))
+
+	for _, definition := range []string{
+		fmt.Sprintf(`tagsuffix("%s","email","")`, signer1),
+		fmt.Sprintf(`tagprefix("%s","email","")`, signer1),
+	} {
+		p := PlacementDefinitions{}
+		err := p.AddPlacementFromString("10:" + definition)
+		require.Error(t, err, definition)
+		require.Contains(t, err.Error(), "non-empty string")
+	}
+}
+
+func TestNumericTag(t *testing.T) {
+	signer, err := storj.NodeIDFromString("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4")
+	require.NoError(t, err)
+
+	p := PlacementDefinitions{}
+	err = p.AddPlacementFromString(fmt.Sprintf(`10:tag("%s","port",28967);11:anytag("port",28967)`, signer))
+	require.NoError(t, err)
+
+	withPort := func(value string) *SelectedNode {
+		return &SelectedNode{
+			Tags: NodeTags{
+				{Signer: signer, Name: "port", Value: []byte(value)},
+			},
+		}
+	}
+
+	for _, placement := range []storj.PlacementConstraint{10, 11} {
+		require.True(t, p[placement].NodeFilter.Match(withPort("28967")))
+		require.False(t, p[placement].NodeFilter.Match(withPort("28968")))
+		require.False(t, p[placement].NodeFilter.Match(withPort("028967")))
+	}
+}
+
+func TestAnySignerTag(t *testing.T) {
+	signer1 := testidentity.MustPregeneratedSignedIdentity(0, storj.LatestIDVersion()).ID
+	signer2 := testidentity.MustPregeneratedSignedIdentity(1, storj.LatestIDVersion()).ID
+
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(fmt.Sprintf(`10:anytag("owner","storj");11:tag("%s","owner","storj")`, signer1))
+	require.NoError(t, err)
+
+	taggedBy := func(signer storj.NodeID, value string) *SelectedNode {
+		return &SelectedNode{
+			Tags: NodeTags{
+				{Signer: signer, Name: "owner", Value: []byte(value)},
+			},
+		}
+	}
+
+	require.True(t, p[10].NodeFilter.Match(taggedBy(signer1, "storj")))
+	require.True(t, p[10].NodeFilter.Match(taggedBy(signer2, "storj")))
+	require.False(t, p[10].NodeFilter.Match(taggedBy(signer2, "other")))
+	require.False(t, p[10].NodeFilter.Match(&SelectedNode{}))
+
+	require.True(t, p[11].NodeFilter.Match(taggedBy(signer1, "storj")))
+	require.False(t, p[11].NodeFilter.Match(taggedBy(signer2, "storj")))
+
+	require.Equal(t, `anytag("owner","storj")`, fmt.Sprintf("%s", p[10].NodeFilter))
+}
+
+func TestReloadablePlacementDefinitions(t *testing.T) {
+	initial := TestPlacementDefinitions()
+	require.NoError(t, initial.AddPlacementFromString(`10:country("DE")`))
+
+	reloadable := NewReloadablePlacementDefinitions(initial)
+	require.True(t, reloadable.CreateFilters(10).Match(&SelectedNode{CountryCode: location.Germany}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				filter := reloadable.CreateFilters(10)
+				_ = filter.Match(&SelectedNode{CountryCode: location.Germany})
+				_ = reloadable.Load().SupportedPlacements()
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		next := TestPlacementDefinitions()
+		country := "DE"
+		if i%2 == 1 {
+			country = "US"
+		}
+		require.NoError(t, next.AddPlacementFromString(fmt.Sprintf(`10:country("%s")`, country)))
+		reloadable.Reload(next)
+	}
+	wg.Wait()
+
+	// the last reload defined placement 10 for US
+	require.True(t, reloadable.CreateFilters(10).Match(&SelectedNode{CountryCode: location.UnitedStates}))
+	require.False(t, reloadable.CreateFilters(10).Match(&SelectedNode{CountryCode: location.Germany}))
+
+	_, err := reloadable.CreateFiltersWithError(10)
+	require.NoError(t, err)
+	_, err = reloadable.CreateFiltersWithError(11)
+	require.True(t, ErrUnknownPlacement.Has(err))
+	require.True(t, reloadable.AutoExcludeSubnet(10))
+
+	t.Run("zero value", func(t *testing.T) {
+		var empty ReloadablePlacementDefinitions
+		require.Empty(t, empty.Load())
+		require.Empty(t, empty.Load().SupportedPlacements())
+		_, err := empty.CreateFiltersWithError(0)
+		require.True(t, ErrUnknownPlacement.Has(err))
+	})
+}
+
+func TestAutoExcludeSubnet(t *testing.T) {
+	p := TestPlacementDefinitions()
+	err := p.AddPlacementFromString(`10:annotated(country("DE"),annotation("autoExcludeSubnet","off"));11:country("DE");12:annotated(country("DE"),annotation("autoExcludeSubnet","on"))`)
+	require.NoError(t, err)
+
+	require.False(t, p.AutoExcludeSubnet(10))
+	require.True(t, p.AutoExcludeSubnet(11))
+	require.True(t, p.AutoExcludeSubnet(12))
+	require.True(t, p.AutoExcludeSubnet(0))
+	require.True(t, p.AutoExcludeSubnet(13))
+
+	t.Run("yaml", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "placement.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`placements:
+  - id: 1
+    filter: country("DE")
+    selector: random()
+  - id: 2
+    filter: country("DE")
+    selector: attribute("last_net")
+  - id: 3
+    filter: country("DE")
+    invariant: maxcontrol("last_net",1)
+    selector: random()
+  - id: 4
+    filter: country("DE")
+    selector: filter(tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo","bar"), random())
+  - id: 5
+    filter: country("DE")
+    selector: filter(tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo","bar"), attribute("last_net"))
+  - id: 6
+    filter: country("DE")
+    invariant: maxcontrol("last_net",2)
+    selector: unvetted(0.1, attribute("last_net"))
+  - id: 7
+    filter: country("DE")
+    invariant: maxcontrol("last_net",2)
+    selector: attribute("wallet")
+`), 0644))
+
+		p, err := LoadConfig(path)
+		require.NoError(t, err)
+
+		require.False(t, p.AutoExcludeSubnet(1))
+		require.True(t, p.AutoExcludeSubnet(2))
+		require.True(t, p.AutoExcludeSubnet(3))
+		require.False(t, p.AutoExcludeSubnet(4))
+		require.True(t, p.AutoExcludeSubnet(5))
+		require.True(t, p.AutoExcludeSubnet(6))
+		require.False(t, p.AutoExcludeSubnet(7))
+	})
+}
+
+func TestPlacementFromStringErrors(t *testing.T) {
+	p := PlacementDefinitions{}
+	err := p.AddPlacementFromString(`10:country("GB");11:contry("DE")`)
+	require.Error(t, err)
+	require.True(t, ErrPlacement.Has(err))
+	require.Contains(t, err.Error(), `11:contry("DE")`)
+	for _, name := range []string{"country", "tag", "placement", "all", "annotated", "annotation", "exclude", "empty", "notEmpty"} {
+		require.Contains(t, err.Error(), name)
+	}
+}
+
+func TestCountrySpread(t *testing.T) {
+	p := NewPlacementDefinitions()
+	err := p.AddPlacementFromString(`10:annotated(country("DE","FR","PL") && spread(country, 2),annotation("location","spread"));11:country("DE")`)
+	require.NoError(t, err)
+
+	node := func(country location.CountryCode) *SelectedNode {
+		return &SelectedNode{CountryCode: country}
+	}
+
+	// set constraints don't restrict the individual nodes.
+	require.True(t, p.CreateFilters(10).Match(node(location.Germany)))
+	require.False(t, p.CreateFilters(10).Match(node(location.Hungary)))
+
+	constraints := p.PlacementConstraints(10)
+	require.Equal(t, []SetConstraint{CountrySpread{Min: 2}}, constraints)
+	require.Empty(t, p.PlacementConstraints(11))
+	require.Empty(t, p.PlacementConstraints(12))
+
+	err = constraints[0].Check([]*SelectedNode{node(location.Germany), node(location.Germany), node(location.None)})
+	require.True(t, ErrSetConstraint.Has(err))
+	require.NoError(t, constraints[0].Check([]*SelectedNode{node(location.Germany), node(location.France)}))
+
+	// the attribute can be passed as a string too.
+	rule := ConfigurablePlacementRule{PlacementRules: `10:spread("country",3)`}
+	constraints, err = rule.PlacementConstraints(10)
+	require.NoError(t, err)
+	require.Equal(t, []SetConstraint{CountrySpread{Min: 3}}, constraints)
+
+	filter, err := FilterFromString(`spread(country, 3) && continent("europe")`)
+	require.NoError(t, err)
+	require.Equal(t, []SetConstraint{CountrySpread{Min: 3}}, SetConstraints(filter))
+
+	constraints, err = ConfigurablePlacementRule{}.PlacementConstraints(10)
+	require.NoError(t, err)
+	require.Empty(t, constraints)
+
+	for _, definition := range []string{
+		`10:spread("last_net",2)`,
+		`10:spread("country",0)`,
+		`10:spread(country,0)`,
+		`10:spread(continent,2)`,
+		`10:spread(3,2)`,
+	} {
+		require.Error(t, NewPlacementDefinitions().AddPlacementFromString(definition), definition)
+	}
+}
+
+func TestConfigurablePlacementRuleValidate(t *testing.T) {
+	require.NoError(t, ConfigurablePlacementRule{}.Validate())
+	require.NoError(t, ConfigurablePlacementRule{PlacementRules: `10:country("XK","DE") || exclude(country("eu"))`}.Validate())
+
+	err := ConfigurablePlacementRule{PlacementRules: `10:country("DE","QQ");11:not(country("*","!QZ")) && tag("12whfK1EDvHJtajBiAUeajQLYcWqxcQmdYQU5zX5cCf6bAxfgu4","foo","bar");12:atLeast(1,country("US"),country("ZZ"))`}.Validate()
+	require.Error(t, err)
+	require.True(t, ErrPlacement.Has(err))
+	for _, problem := range []string{
+		`placement 10: unknown country code "QQ"`,
+		`placement 11: unknown country code "!QZ"`,
+		`placement 12: unknown country code "ZZ"`,
+	} {
+		require.Contains(t, err.Error(), problem)
+	}
+	require.Equal(t, 3, strings.Count(err.Error(), "unknown country code"), err.Error())
+
+	err = ConfigurablePlacementRule{PlacementRules: `10:country("D")`}.Validate()
+	require.Error(t, err)
+
+	_, err = NewCountryFilterFromString([]string{""})
+	require.Error(t, err)
+}
+
+func TestVettedPlacement(t *testing.T) {
+	p := NewPlacementDefinitions()
+	err := p.AddPlacementFromString(`10:vetted(true);11:vetted(false);12:country("EU") && vetted(true)`)
+	require.NoError(t, err)
+
+	vettedDE := &SelectedNode{CountryCode: location.Germany, Vetted: true}
+	unvettedDE := &SelectedNode{CountryCode: location.Germany}
+	vettedUS := &SelectedNode{CountryCode: location.UnitedStates, Vetted: true}
+
+	require.True(t, p.CreateFilters(10).Match(vettedDE))
+	require.False(t, p.CreateFilters(10).Match(unvettedDE))
+
+	require.False(t, p.CreateFilters(11).Match(vettedDE))
+	require.True(t, p.CreateFilters(11).Match(unvettedDE))
+
+	require.True(t, p.CreateFilters(12).Match(vettedDE))
+	require.False(t, p.CreateFilters(12).Match(unvettedDE))
+	require.False(t, p.CreateFilters(12).Match(vettedUS))
+
+	filter, err := FilterFromString(`vetted(false)`)
+	require.NoError(t, err)
+	require.True(t, filter.Match(unvettedDE))
+	require.False(t, filter.Match(vettedDE))
+}
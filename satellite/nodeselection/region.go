@@ -42,3 +42,271 @@ var EeaCountriesWithoutEu = []location.CountryCode{
 	location.Liechtenstein,
 	location.Norway,
 }
+
+// Continents defines the countries and territories on each continent.
+var Continents = map[string][]location.CountryCode{
+	"africa": {
+		location.Angola,
+		location.BurkinaFaso,
+		location.Burundi,
+		location.Benin,
+		location.Botswana,
+		location.DemocraticRepublicoftheCongo,
+		location.CentralAfricanRepublic,
+		location.RepublicoftheCongo,
+		location.IvoryCoast,
+		location.Cameroon,
+		location.CaboVerde,
+		location.Djibouti,
+		location.Algeria,
+		location.Egypt,
+		location.WesternSahara,
+		location.Eritrea,
+		location.Ethiopia,
+		location.Gabon,
+		location.Ghana,
+		location.Gambia,
+		location.Guinea,
+		location.EquatorialGuinea,
+		location.GuineaBissau,
+		location.BritishIndianOceanTerritory,
+		location.Kenya,
+		location.Comoros,
+		location.Liberia,
+		location.Lesotho,
+		location.Libya,
+		location.Morocco,
+		location.Madagascar,
+		location.Mali,
+		location.Mauritania,
+		location.Mauritius,
+		location.Malawi,
+		location.Mozambique,
+		location.Namibia,
+		location.Niger,
+		location.Nigeria,
+		location.Reunion,
+		location.Rwanda,
+		location.Seychelles,
+		location.Sudan,
+		location.SaintHelena,
+		location.SierraLeone,
+		location.Senegal,
+		location.Somalia,
+		location.SouthSudan,
+		location.SaoTomeandPrincipe,
+		location.Eswatini,
+		location.Chad,
+		location.Togo,
+		location.Tunisia,
+		location.Tanzania,
+		location.Uganda,
+		location.Mayotte,
+		location.SouthAfrica,
+		location.Zambia,
+		location.Zimbabwe,
+	},
+	"antarctica": {
+		location.Antarctica,
+		location.BouvetIsland,
+		location.SouthGeorgiaandtheSouthSandwichIslands,
+		location.HeardIslandandMcDonaldIslands,
+		location.FrenchSouthernTerritories,
+	},
+	"asia": {
+		location.UnitedArabEmirates,
+		location.Afghanistan,
+		location.Armenia,
+		location.Azerbaijan,
+		location.Bangladesh,
+		location.Bahrain,
+		location.Brunei,
+		location.Bhutan,
+		location.China,
+		location.Georgia,
+		location.HongKong,
+		location.Indonesia,
+		location.Israel,
+		location.India,
+		location.Iraq,
+		location.Iran,
+		location.Jordan,
+		location.Japan,
+		location.Kyrgyzstan,
+		location.Cambodia,
+		location.NorthKorea,
+		location.SouthKorea,
+		location.Kuwait,
+		location.Kazakhstan,
+		location.Laos,
+		location.Lebanon,
+		location.SriLanka,
+		location.Myanmar,
+		location.Mongolia,
+		location.Macao,
+		location.Maldives,
+		location.Malaysia,
+		location.Nepal,
+		location.Oman,
+		location.Philippines,
+		location.Pakistan,
+		location.PalestinianTerritory,
+		location.Qatar,
+		location.SaudiArabia,
+		location.Singapore,
+		location.Syria,
+		location.Thailand,
+		location.Tajikistan,
+		location.TimorLeste,
+		location.Turkmenistan,
+		location.Turkey,
+		location.Taiwan,
+		location.Uzbekistan,
+		location.Vietnam,
+		location.Yemen,
+	},
+	"europe": {
+		location.Andorra,
+		location.Albania,
+		location.Austria,
+		location.AlandIslands,
+		location.BosniaandHerzegovina,
+		location.Belgium,
+		location.Bulgaria,
+		location.Belarus,
+		location.Switzerland,
+		location.Cyprus,
+		location.Czechia,
+		location.Germany,
+		location.Denmark,
+		location.Estonia,
+		location.Spain,
+		location.Finland,
+		location.FaroeIslands,
+		location.France,
+		location.UnitedKingdom,
+		location.Guernsey,
+		location.Gibraltar,
+		location.Greece,
+		location.Croatia,
+		location.Hungary,
+		location.Ireland,
+		location.IsleofMan,
+		location.Iceland,
+		location.Italy,
+		location.Jersey,
+		location.Liechtenstein,
+		location.Lithuania,
+		location.Luxembourg,
+		location.Latvia,
+		location.Monaco,
+		location.Moldova,
+		location.Montenegro,
+		location.NorthMacedonia,
+		location.Malta,
+		location.Netherlands,
+		location.Norway,
+		location.Poland,
+		location.Portugal,
+		location.Romania,
+		location.Serbia,
+		location.Russia,
+		location.Sweden,
+		location.Slovenia,
+		location.SvalbardandJanMayen,
+		location.Slovakia,
+		location.SanMarino,
+		location.Ukraine,
+		location.Vatican,
+		location.Kosovo,
+	},
+	"north-america": {
+		location.AntiguaandBarbuda,
+		location.Anguilla,
+		location.Aruba,
+		location.Barbados,
+		location.SaintBarthelemy,
+		location.Bermuda,
+		location.BonaireSaintEustatiusandSaba,
+		location.Bahamas,
+		location.Belize,
+		location.Canada,
+		location.CostaRica,
+		location.Cuba,
+		location.Curacao,
+		location.Dominica,
+		location.DominicanRepublic,
+		location.Grenada,
+		location.Greenland,
+		location.Guadeloupe,
+		location.Guatemala,
+		location.Honduras,
+		location.Haiti,
+		location.Jamaica,
+		location.SaintKittsandNevis,
+		location.CaymanIslands,
+		location.SaintLucia,
+		location.SaintMartin,
+		location.Martinique,
+		location.Montserrat,
+		location.Mexico,
+		location.Nicaragua,
+		location.Panama,
+		location.SaintPierreandMiquelon,
+		location.PuertoRico,
+		location.ElSalvador,
+		location.SintMaarten,
+		location.TurksandCaicosIslands,
+		location.TrinidadandTobago,
+		location.UnitedStatesMinorOutlyingIslands,
+		location.UnitedStates,
+		location.SaintVincentandtheGrenadines,
+		location.BritishVirginIslands,
+		location.USVirginIslands,
+	},
+	"south-america": {
+		location.Argentina,
+		location.Bolivia,
+		location.Brazil,
+		location.Chile,
+		location.Colombia,
+		location.Ecuador,
+		location.FalklandIslands,
+		location.FrenchGuiana,
+		location.Guyana,
+		location.Peru,
+		location.Paraguay,
+		location.Suriname,
+		location.Uruguay,
+		location.Venezuela,
+	},
+	"oceania": {
+		location.AmericanSamoa,
+		location.Australia,
+		location.CocosIslands,
+		location.CookIslands,
+		location.ChristmasIsland,
+		location.Fiji,
+		location.Micronesia,
+		location.Guam,
+		location.Kiribati,
+		location.MarshallIslands,
+		location.NorthernMarianaIslands,
+		location.NewCaledonia,
+		location.NorfolkIsland,
+		location.Nauru,
+		location.Niue,
+		location.NewZealand,
+		location.FrenchPolynesia,
+		location.PapuaNewGuinea,
+		location.Pitcairn,
+		location.Palau,
+		location.SolomonIslands,
+		location.Tokelau,
+		location.Tonga,
+		location.Tuvalu,
+		location.Vanuatu,
+		location.WallisandFutuna,
+		location.Samoa,
+	},
+}
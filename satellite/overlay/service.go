@@ -306,7 +306,7 @@ type Service struct {
 	UploadSelectionCache   *UploadSelectionCache
 	DownloadSelectionCache *DownloadSelectionCache
 	LastNetFunc            LastNetFunc
-	placementDefinitions   nodeselection.PlacementDefinitions
+	placementDefinitions   *nodeselection.ReloadablePlacementDefinitions
 }
 
 // LastNetFunc is the type of a function that will be used to derive a network from an ip and port.
@@ -327,6 +327,8 @@ func NewService(log *zap.Logger, db DB, nodeEvents nodeevents.DB, placements nod
 		}
 	}
 
+	placementDefinitions := nodeselection.NewReloadablePlacementDefinitions(placements)
+
 	defaultSelection := nodeselection.NodeFilters{}
 
 	if len(config.Node.UploadExcludedCountryCodes) > 0 {
@@ -341,15 +343,15 @@ func NewService(log *zap.Logger, db DB, nodeEvents nodeevents.DB, placements nod
 		defaultSelection = defaultSelection.WithCountryFilter(set)
 	}
 
-	uploadSelectionCache, err := NewUploadSelectionCache(log, db,
+	uploadSelectionCache, err := newUploadSelectionCache(log, db,
 		config.NodeSelectionCache.Staleness, config.Node,
-		defaultSelection, placements,
+		defaultSelection, placementDefinitions,
 	)
 	if err != nil {
 		return nil, errs.Wrap(err)
 	}
 	downloadSelectionCache, err := NewDownloadSelectionCache(log, db,
-		placements.CreateFilters,
+		placementDefinitions.CreateFilters,
 		DownloadSelectionCacheConfig{
 			Staleness:      config.NodeSelectionCache.Staleness,
 			OnlineWindow:   config.Node.OnlineWindow,
@@ -374,10 +376,16 @@ func NewService(log *zap.Logger, db DB, nodeEvents nodeevents.DB, placements nod
 		DownloadSelectionCache: downloadSelectionCache,
 		LastNetFunc:            MaskOffLastNet,
 
-		placementDefinitions: placements,
+		placementDefinitions: placementDefinitions,
 	}, nil
 }
 
+// ReloadPlacements replaces the placement definitions used by the service. The node selection
+// caches use the new definitions after their next refresh.
+func (service *Service) ReloadPlacements(placements nodeselection.PlacementDefinitions) {
+	service.placementDefinitions.Reload(placements)
+}
+
 // Run runs the background processes needed for caches.
 func (service *Service) Run(ctx context.Context) error {
 	return errs.Combine(sync2.Concurrently(
@@ -720,6 +728,23 @@ func (service *Service) SelectAllStorageNodesDownload(ctx context.Context, onlin
 	return service.db.SelectAllStorageNodesDownload(ctx, onlineWindow, asOf)
 }
 
+// EvaluatePlacement returns how many of the given nodes would be accepted by the placement.
+func (service *Service) EvaluatePlacement(ctx context.Context, id storj.PlacementConstraint, nodes []nodeselection.SelectedNode) (matched int, total int, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	filter, err := service.placementDefinitions.CreateFiltersWithError(id)
+	if err != nil {
+		return 0, 0, Error.Wrap(err)
+	}
+
+	for i := range nodes {
+		if filter.Match(&nodes[i]) {
+			matched++
+		}
+	}
+	return matched, len(nodes), nil
+}
+
 // ResolveIPAndNetwork resolves the target address and determines its IP and appropriate subnet IPv4 or subnet IPv6.
 func (service *Service) ResolveIPAndNetwork(ctx context.Context, target string) (ip net.IP, port, network string, err error) {
 	// LastNetFunc is MaskOffLastNet, unless changed for a test.
@@ -739,7 +764,7 @@ func (service *Service) GetNodeTags(ctx context.Context, id storj.NodeID) (nodes
 // GetLocationFromPlacement returns the location identifier of the bucket.
 // It comes from the name of the placement (or `nodeselection.Location` in case of legacy config).
 func (service *Service) GetLocationFromPlacement(placement storj.PlacementConstraint) string {
-	return service.placementDefinitions[placement].Name
+	return service.placementDefinitions.Load()[placement].Name
 }
 
 // ResolveIPAndNetwork resolves the target address and determines its IP and appropriate last_net, as indicated.
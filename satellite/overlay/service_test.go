@@ -17,6 +17,7 @@ import (
 	"storj.io/common/memory"
 	"storj.io/common/pb"
 	"storj.io/common/storj"
+	"storj.io/common/storj/location"
 	"storj.io/common/testcontext"
 	"storj.io/common/testrand"
 	"storj.io/storj/private/testplanet"
@@ -381,6 +382,45 @@ func TestNodeInfo(t *testing.T) {
 	})
 }
 
+func TestEvaluatePlacement(t *testing.T) {
+	testplanet.Run(t, testplanet.Config{
+		SatelliteCount: 1, StorageNodeCount: 0, UplinkCount: 0,
+	}, func(t *testing.T, ctx *testcontext.Context, planet *testplanet.Planet) {
+		service := planet.Satellites[0].Overlay.Service
+
+		nodes := []nodeselection.SelectedNode{
+			{ID: testrand.NodeID(), CountryCode: location.Germany},
+			{ID: testrand.NodeID(), CountryCode: location.France},
+			{ID: testrand.NodeID(), CountryCode: location.UnitedStates},
+		}
+
+		matched, total, err := service.EvaluatePlacement(ctx, storj.EU, nodes)
+		require.NoError(t, err)
+		require.Equal(t, 2, matched)
+		require.Equal(t, 3, total)
+
+		matched, total, err = service.EvaluatePlacement(ctx, storj.US, nodes)
+		require.NoError(t, err)
+		require.Equal(t, 1, matched)
+		require.Equal(t, 3, total)
+
+		_, _, err = service.EvaluatePlacement(ctx, storj.PlacementConstraint(100), nodes)
+		require.Error(t, err)
+
+		placements := nodeselection.PlacementDefinitions{}
+		require.NoError(t, placements.AddPlacementFromString(`100:country("DE")`))
+		service.ReloadPlacements(placements)
+
+		matched, total, err = service.EvaluatePlacement(ctx, storj.PlacementConstraint(100), nodes)
+		require.NoError(t, err)
+		require.Equal(t, 1, matched)
+		require.Equal(t, 3, total)
+
+		_, _, err = service.EvaluatePlacement(ctx, storj.EU, nodes)
+		require.Error(t, err)
+	})
+}
+
 func TestGetNodes(t *testing.T) {
 	testplanet.Run(t, testplanet.Config{
 		SatelliteCount: 1, StorageNodeCount: 6, UplinkCount: 1,
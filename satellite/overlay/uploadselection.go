@@ -38,11 +38,17 @@ type UploadSelectionCache struct {
 	cache sync2.ReadCacheOf[nodeselection.State]
 
 	defaultFilters nodeselection.NodeFilters
-	placements     nodeselection.PlacementDefinitions
+	placements     *nodeselection.ReloadablePlacementDefinitions
 }
 
 // NewUploadSelectionCache creates a new cache that keeps a list of all the storage nodes that are qualified to store data.
 func NewUploadSelectionCache(log *zap.Logger, db UploadSelectionDB, staleness time.Duration, config NodeSelectionConfig, defaultFilter nodeselection.NodeFilters, placements nodeselection.PlacementDefinitions) (*UploadSelectionCache, error) {
+	return newUploadSelectionCache(log, db, staleness, config, defaultFilter, nodeselection.NewReloadablePlacementDefinitions(placements))
+}
+
+// newUploadSelectionCache creates a new upload selection cache, which uses the current placement definitions
+// when the cache is refreshed.
+func newUploadSelectionCache(log *zap.Logger, db UploadSelectionDB, staleness time.Duration, config NodeSelectionConfig, defaultFilter nodeselection.NodeFilters, placements *nodeselection.ReloadablePlacementDefinitions) (*UploadSelectionCache, error) {
 	cache := &UploadSelectionCache{
 		log:             log,
 		db:              db,
@@ -81,7 +87,7 @@ func (cache *UploadSelectionCache) read(ctx context.Context) (_ nodeselection.St
 	mon.IntVal("refresh_cache_size_new").Observe(int64(len(newNodes)))
 
 	var allNodes = append(append([]*nodeselection.SelectedNode{}, reputableNodes...), newNodes...)
-	state := nodeselection.NewState(allNodes, cache.placements)
+	state := nodeselection.NewState(allNodes, cache.placements.Load())
 	return state, nil
 }
 
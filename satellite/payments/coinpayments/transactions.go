@@ -9,6 +9,8 @@ import "strings"
 type Status int
 
 const (
+	// StatusTimedOut defines pending transaction which didn't receive the funds before its timeout.
+	StatusTimedOut Status = -2
 	// StatusCancelled defines cancelled or timeout transaction.
 	StatusCancelled Status = -1
 	// StatusPending defines pending transaction which is waiting for buyer funds.
@@ -27,6 +29,8 @@ func (s Status) Int() int {
 // String returns string representation of status.
 func (s Status) String() string {
 	switch s {
+	case StatusTimedOut:
+		return "timed out"
 	case StatusCancelled:
 		return "cancelled/timeout"
 	case StatusPending:
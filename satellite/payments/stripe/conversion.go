@@ -16,3 +16,20 @@ func convertToCents(rate decimal.Decimal, amount currency.Amount) int64 {
 	usdCents := usd.Shift(2)
 	return usdCents.Round(0).IntPart()
 }
+
+// FormatAmount formats the amount with at most the decimal places of its currency,
+// trailing zeros are trimmed (e.g. 1.5 for 1.50 USD and 1 for 1.00000000 STORJ).
+func FormatAmount(amount currency.Amount) string {
+	return amount.AsDecimal().Round(amount.Currency().DecimalPlaces()).String()
+}
+
+// RescaleAmount converts the amount to the given currency keeping its value.
+// It fails when the value can't be represented exactly with the decimal places of the currency.
+func RescaleAmount(amount currency.Amount, to *currency.Currency) (currency.Amount, error) {
+	value := amount.AsDecimal()
+	rescaled := currency.AmountFromDecimal(value, to)
+	if !rescaled.AsDecimal().Equal(value) {
+		return currency.Amount{}, Error.New("amount %s can't be represented exactly in %s", FormatAmount(amount), to.Symbol())
+	}
+	return rescaled, nil
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package stripe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/common/currency"
+	"storj.io/storj/satellite/payments/stripe"
+)
+
+func TestFormatAmount(t *testing.T) {
+	for _, tc := range []struct {
+		amount   currency.Amount
+		expected string
+	}{
+		{currency.AmountFromBaseUnits(0, currency.StorjToken), "0"},
+		{currency.AmountFromBaseUnits(1, currency.StorjToken), "0.00000001"},
+		{currency.AmountFromBaseUnits(100000000, currency.StorjToken), "1"},
+		{currency.AmountFromBaseUnits(150000000, currency.StorjToken), "1.5"},
+		{currency.AmountFromBaseUnits(100000010, currency.StorjToken), "1.0000001"},
+		{currency.AmountFromBaseUnits(1234567890123, currency.StorjToken), "12345.67890123"},
+		{currency.AmountFromBaseUnits(0, currency.USDollars), "0"},
+		{currency.AmountFromBaseUnits(5, currency.USDollars), "0.05"},
+		{currency.AmountFromBaseUnits(150, currency.USDollars), "1.5"},
+		{currency.AmountFromBaseUnits(-150, currency.USDollars), "-1.5"},
+		{currency.AmountFromBaseUnits(100000, currency.USDollars), "1000"},
+	} {
+		require.Equal(t, tc.expected, stripe.FormatAmount(tc.amount))
+	}
+}
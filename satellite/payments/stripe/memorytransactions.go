@@ -0,0 +1,547 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package stripe
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"storj.io/common/currency"
+	"storj.io/common/uuid"
+	"storj.io/storj/satellite/payments/coinpayments"
+)
+
+// ensure that MemoryTransactionsDB implements TransactionsDB.
+var _ TransactionsDB = (*MemoryTransactionsDB)(nil)
+
+// MemoryTransactionsDB is an in-memory implementation of TransactionsDB,
+// which can be used to test billing code without a satellite DB.
+type MemoryTransactionsDB struct {
+	mu           sync.Mutex
+	transactions map[coinpayments.TransactionID]Transaction
+	rates        map[coinpayments.TransactionID]memoryRate
+	intents      map[coinpayments.TransactionID]memoryIntent
+}
+
+// memoryRate is a locked conversion rate of MemoryTransactionsDB.
+type memoryRate struct {
+	rate     decimal.Decimal
+	lockedAt time.Time
+}
+
+// memoryIntent is an apply balance intent of MemoryTransactionsDB.
+type memoryIntent struct {
+	consumed  bool
+	createdAt time.Time
+}
+
+// NewMemoryTransactionsDB creates an empty in-memory TransactionsDB.
+func NewMemoryTransactionsDB() *MemoryTransactionsDB {
+	return &MemoryTransactionsDB{
+		transactions: map[coinpayments.TransactionID]Transaction{},
+		rates:        map[coinpayments.TransactionID]memoryRate{},
+		intents:      map[coinpayments.TransactionID]memoryIntent{},
+	}
+}
+
+// GetLockedRate returns locked conversion rate for transaction or ErrRateNotLocked if non exists.
+func (db *MemoryTransactionsDB) GetLockedRate(ctx context.Context, id coinpayments.TransactionID) (decimal.Decimal, error) {
+	rate, _, err := db.GetLockedRateWithTime(ctx, id)
+	return rate, err
+}
+
+// GetLockedRateRounded returns locked conversion rate for transaction rounded to the given decimal places
+// or ErrRateNotLocked if non exists.
+func (db *MemoryTransactionsDB) GetLockedRateRounded(ctx context.Context, id coinpayments.TransactionID, places int32) (decimal.Decimal, error) {
+	rate, _, err := db.GetLockedRateWithTime(ctx, id)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return rate.Round(places), nil
+}
+
+// GetLockedRateWithTime returns locked conversion rate for transaction and the time it was locked at
+// or ErrRateNotLocked if non exists.
+func (db *MemoryTransactionsDB) GetLockedRateWithTime(ctx context.Context, id coinpayments.TransactionID) (decimal.Decimal, time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rate, ok := db.rates[id]
+	if !ok {
+		return decimal.Decimal{}, time.Time{}, ErrRateNotLocked
+	}
+	return rate.rate, rate.lockedAt, nil
+}
+
+// Get returns transaction with the given id.
+func (db *MemoryTransactionsDB) Get(ctx context.Context, id coinpayments.TransactionID) (*Transaction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, ok := db.transactions[id]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return &tx, nil
+}
+
+// ListAccount returns all transaction for specific user, ordered by creation time descending.
+func (db *MemoryTransactionsDB) ListAccount(ctx context.Context, userID uuid.UUID) ([]Transaction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.list(func(tx Transaction) bool {
+		return tx.AccountID == userID
+	}), nil
+}
+
+// ListAccountPage returns a page of transactions for specific user, ordered by creation time descending.
+func (db *MemoryTransactionsDB) ListAccountPage(ctx context.Context, userID uuid.UUID, offset int64, limit int) (page TransactionsPage, err error) {
+	if limit <= 0 {
+		return TransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	txs, err := db.ListAccount(ctx, userID)
+	if err != nil {
+		return TransactionsPage{}, err
+	}
+
+	if offset >= int64(len(txs)) {
+		return TransactionsPage{}, nil
+	}
+	txs = txs[offset:]
+
+	if len(txs) > limit {
+		page.Next = true
+		page.NextOffset = offset + int64(limit)
+
+		txs = txs[:limit]
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// ListPartialPayments returns received transactions created before the given time, which received
+// less than the requested amount, ordered by creation time descending.
+func (db *MemoryTransactionsDB) ListPartialPayments(ctx context.Context, before time.Time, limit int) (page TransactionsPage, err error) {
+	if limit <= 0 {
+		return TransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	txs := db.list(func(tx Transaction) bool {
+		return tx.Status == coinpayments.StatusReceived && !tx.CreatedAt.After(before) &&
+			tx.Received.BaseUnits() < tx.Amount.BaseUnits()
+	})
+
+	if len(txs) > limit {
+		page.Next = true
+		txs = txs[:limit]
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// ExportAccount returns all transactions for specific user in the data export format.
+func (db *MemoryTransactionsDB) ExportAccount(ctx context.Context, userID uuid.UUID) ([]TransactionExport, error) {
+	txs, err := db.ListAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exports := make([]TransactionExport, 0, len(txs))
+	for _, tx := range txs {
+		exports = append(exports, tx.Export())
+	}
+	return exports, nil
+}
+
+// ListAccountFiltered returns transactions for specific user which have one of the given
+// statuses and were created at or after the given time. Empty statuses matches all statuses.
+func (db *MemoryTransactionsDB) ListAccountFiltered(ctx context.Context, userID uuid.UUID, statuses []coinpayments.Status, after time.Time) ([]Transaction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.list(func(tx Transaction) bool {
+		if tx.AccountID != userID || tx.CreatedAt.Before(after) {
+			return false
+		}
+		if len(statuses) == 0 {
+			return true
+		}
+		for _, status := range statuses {
+			if tx.Status == status {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// TotalReceived returns the total amount received in the given currency
+// by the user's transactions which have at least the received status.
+func (db *MemoryTransactionsDB) TotalReceived(ctx context.Context, userID uuid.UUID, curr *currency.Currency) (currency.Amount, error) {
+	if curr == nil {
+		return currency.Amount{}, Error.New("currency is not specified")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var total int64
+	for _, tx := range db.transactions {
+		if tx.AccountID == userID && tx.Status >= coinpayments.StatusReceived && tx.Received.Currency().Symbol() == curr.Symbol() {
+			total += tx.Received.BaseUnits()
+		}
+	}
+	return currency.AmountFromBaseUnits(total, curr), nil
+}
+
+// InsertWithRate inserts new coinpayments transaction and locks its conversion rate atomically.
+func (db *MemoryTransactionsDB) InsertWithRate(ctx context.Context, tx Transaction, rate decimal.Decimal) (*Transaction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.rates[tx.ID]; ok {
+		return nil, Error.New("conversion rate for transaction %q is already locked", tx.ID)
+	}
+
+	tx, err := db.insert(tx)
+	if err != nil {
+		return nil, err
+	}
+	db.rates[tx.ID] = memoryRate{rate: rate, lockedAt: tx.CreatedAt}
+	return &tx, nil
+}
+
+// LockRates locks conversion rates for multiple transactions at once.
+func (db *MemoryTransactionsDB) LockRates(ctx context.Context, rates map[coinpayments.TransactionID]decimal.Decimal) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id := range rates {
+		if _, ok := db.rates[id]; ok {
+			return Error.New("conversion rate for transaction %q is already locked", id)
+		}
+	}
+
+	now := time.Now()
+	for id, rate := range rates {
+		db.rates[id] = memoryRate{rate: rate, lockedAt: now}
+	}
+	return nil
+}
+
+// DeleteExpired deletes pending and timed out transactions which timed out before the given time.
+// Transactions which have an apply balance intent are kept.
+func (db *MemoryTransactionsDB) DeleteExpired(ctx context.Context, before time.Time) (deleted int64, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, tx := range db.transactions {
+		if tx.Status != coinpayments.StatusPending && tx.Status != coinpayments.StatusTimedOut {
+			continue
+		}
+		if !tx.CreatedAt.Add(tx.Timeout).Before(before) {
+			continue
+		}
+		if _, ok := db.intents[id]; ok {
+			continue
+		}
+		delete(db.transactions, id)
+		deleted++
+	}
+	return deleted, nil
+}
+
+// MarkTimedOut changes the status of the pending transactions, which timed out before the given time,
+// to timed out.
+func (db *MemoryTransactionsDB) MarkTimedOut(ctx context.Context, before time.Time) (updated int64, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, tx := range db.transactions {
+		if tx.Status == coinpayments.StatusPending && tx.CreatedAt.Add(tx.Timeout).Before(before) {
+			tx.Status = coinpayments.StatusTimedOut
+			db.transactions[id] = tx
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// Reassign moves the transaction to the given account. Transactions which balance
+// was already applied can't be reassigned.
+func (db *MemoryTransactionsDB) Reassign(ctx context.Context, id coinpayments.TransactionID, newAccountID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, ok := db.transactions[id]
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	if db.intents[id].consumed {
+		return ErrTransactionConsumed
+	}
+
+	tx.AccountID = newAccountID
+	db.transactions[id] = tx
+	return nil
+}
+
+// CountUnapplied returns the number of received transactions created before the given time
+// which have an unapplied balance intent.
+func (db *MemoryTransactionsDB) CountUnapplied(ctx context.Context, before time.Time) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return int64(len(db.list(db.unapplied(before)))), nil
+}
+
+// ListAccountsWithUnapplied returns the accounts with the most received transactions created before
+// the given time which have an unapplied balance intent, ordered by the count descending.
+func (db *MemoryTransactionsDB) ListAccountsWithUnapplied(ctx context.Context, before time.Time, limit int) ([]UnappliedAccount, error) {
+	if limit <= 0 {
+		return nil, Error.New("limit must be positive, got %d", limit)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	counts := map[uuid.UUID]int64{}
+	for _, tx := range db.list(db.unapplied(before)) {
+		counts[tx.AccountID]++
+	}
+
+	var accounts []UnappliedAccount
+	for accountID, count := range counts {
+		accounts = append(accounts, UnappliedAccount{AccountID: accountID, Count: count})
+	}
+	sort.Slice(accounts, func(i, k int) bool {
+		if accounts[i].Count != accounts[k].Count {
+			return accounts[i].Count > accounts[k].Count
+		}
+		return accounts[i].AccountID.Less(accounts[k].AccountID)
+	})
+
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+	return accounts, nil
+}
+
+// FindUnappliedMissingRate returns the ids of the received transactions created before the given time
+// which have an unapplied balance intent, but no locked conversion rate.
+func (db *MemoryTransactionsDB) FindUnappliedMissingRate(ctx context.Context, before time.Time) ([]coinpayments.TransactionID, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	unapplied := db.list(db.unapplied(before))
+
+	var ids []coinpayments.TransactionID
+	// ids are returned in the order of creation.
+	for i := len(unapplied) - 1; i >= 0; i-- {
+		if _, ok := db.rates[unapplied[i].ID]; !ok {
+			ids = append(ids, unapplied[i].ID)
+		}
+	}
+	return ids, nil
+}
+
+// ApplyBalanceIntentSummary returns the number of unapplied and consumed apply balance intents
+// created before the given time, and the creation time of the oldest unapplied one.
+func (db *MemoryTransactionsDB) ApplyBalanceIntentSummary(ctx context.Context, before time.Time) (unapplied, consumed int64, oldestUnapplied time.Time, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, intent := range db.intents {
+		if intent.createdAt.After(before) {
+			continue
+		}
+		if intent.consumed {
+			consumed++
+			continue
+		}
+		unapplied++
+		if oldestUnapplied.IsZero() || intent.createdAt.Before(oldestUnapplied) {
+			oldestUnapplied = intent.createdAt
+		}
+	}
+	return unapplied, consumed, oldestUnapplied, nil
+}
+
+// ListUnappliedAfter returns a page of received transactions created before the given time
+// which have an unapplied balance intent. Transactions are ordered by creation time and id
+// descending and the page starts after the transaction identified by afterCreatedAt and afterID.
+// Zero afterCreatedAt starts from the beginning.
+func (db *MemoryTransactionsDB) ListUnappliedAfter(ctx context.Context, afterCreatedAt time.Time, afterID coinpayments.TransactionID, limit int, before time.Time) (page UnappliedTransactionsPage, err error) {
+	if limit <= 0 {
+		return UnappliedTransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	unapplied := db.unapplied(before)
+	txs := db.list(func(tx Transaction) bool {
+		if !unapplied(tx) {
+			return false
+		}
+		if afterCreatedAt.IsZero() {
+			return true
+		}
+		return tx.CreatedAt.Before(afterCreatedAt) || (tx.CreatedAt.Equal(afterCreatedAt) && tx.ID < afterID)
+	})
+
+	if len(txs) > limit {
+		txs = txs[:limit]
+		page.Next = true
+	}
+	if len(txs) > 0 {
+		last := txs[len(txs)-1]
+		page.LastCreatedAt = last.CreatedAt
+		page.LastID = last.ID
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// RescaleAmounts changes the currency of the given transactions from one currency to another and
+// rescales the amounts to the decimal places of the new currency. All the transactions must be in the
+// from currency, so already rescaled transactions are rejected and none of the transactions is changed.
+func (db *MemoryTransactionsDB) RescaleAmounts(ctx context.Context, from, to *currency.Currency, ids []coinpayments.TransactionID) error {
+	if from == nil || to == nil {
+		return Error.New("currency is not specified")
+	}
+	if from.Symbol() == to.Symbol() {
+		return Error.New("transactions are already in %s", to.Symbol())
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rescaled := make([]Transaction, 0, len(ids))
+	for _, id := range ids {
+		tx, ok := db.transactions[id]
+		if !ok || tx.Amount.Currency().Symbol() != from.Symbol() {
+			return Error.New("transaction %q doesn't exist or isn't in %s", id, from.Symbol())
+		}
+
+		var err error
+		if tx.Amount, err = RescaleAmount(tx.Amount, to); err != nil {
+			return err
+		}
+		if tx.Received, err = RescaleAmount(tx.Received, to); err != nil {
+			return err
+		}
+		rescaled = append(rescaled, tx)
+	}
+
+	for _, tx := range rescaled {
+		db.transactions[tx.ID] = tx
+	}
+	return nil
+}
+
+// TestInsert inserts new coinpayments transaction into DB.
+func (db *MemoryTransactionsDB) TestInsert(ctx context.Context, tx Transaction) (time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.insert(tx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tx.CreatedAt, nil
+}
+
+// TestLockRate locks conversion rate for transaction.
+func (db *MemoryTransactionsDB) TestLockRate(ctx context.Context, id coinpayments.TransactionID, rate decimal.Decimal) error {
+	return db.LockRates(ctx, map[coinpayments.TransactionID]decimal.Decimal{id: rate})
+}
+
+// AddApplyBalanceIntent adds an unapplied apply balance intent for the transaction.
+func (db *MemoryTransactionsDB) AddApplyBalanceIntent(ctx context.Context, id coinpayments.TransactionID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.transactions[id]; !ok {
+		return ErrTransactionNotFound
+	}
+	if _, ok := db.intents[id]; ok {
+		return Error.New("apply balance intent for transaction %q already exists", id)
+	}
+
+	db.intents[id] = memoryIntent{createdAt: time.Now()}
+	return nil
+}
+
+// Consume marks the apply balance intent of the transaction as consumed. It fails with
+// ErrTransactionConsumed when the intent was already consumed.
+func (db *MemoryTransactionsDB) Consume(ctx context.Context, id coinpayments.TransactionID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	intent, ok := db.intents[id]
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	if intent.consumed {
+		return ErrTransactionConsumed
+	}
+
+	intent.consumed = true
+	db.intents[id] = intent
+	return nil
+}
+
+// insert stores a new transaction with the current creation time. db.mu must be held.
+func (db *MemoryTransactionsDB) insert(tx Transaction) (Transaction, error) {
+	if tx.Timeout%time.Second != 0 {
+		return Transaction{}, Error.New("timeout should be a whole number of seconds, got %v", tx.Timeout)
+	}
+	if _, ok := db.transactions[tx.ID]; ok {
+		return Transaction{}, Error.New("transaction %q already exists", tx.ID)
+	}
+
+	tx.CreatedAt = time.Now()
+	db.transactions[tx.ID] = tx
+	return tx, nil
+}
+
+// unapplied returns a filter matching the received transactions created before the given time
+// which have an unapplied balance intent. db.mu must be held while using the filter.
+func (db *MemoryTransactionsDB) unapplied(before time.Time) func(Transaction) bool {
+	return func(tx Transaction) bool {
+		intent, ok := db.intents[tx.ID]
+		return ok && !intent.consumed && tx.Status >= coinpayments.StatusReceived && !tx.CreatedAt.After(before)
+	}
+}
+
+// list returns the transactions matching the filter, ordered by creation time and id descending.
+// db.mu must be held.
+func (db *MemoryTransactionsDB) list(match func(Transaction) bool) []Transaction {
+	var txs []Transaction
+	for _, tx := range db.transactions {
+		if match(tx) {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, k int) bool {
+		if !txs[i].CreatedAt.Equal(txs[k].CreatedAt) {
+			return txs[i].CreatedAt.After(txs[k].CreatedAt)
+		}
+		return txs[i].ID > txs[k].ID
+	})
+	return txs
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package stripe_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"storj.io/common/currency"
+	"storj.io/common/testcontext"
+	"storj.io/common/testrand"
+	"storj.io/storj/satellite/payments/coinpayments"
+	"storj.io/storj/satellite/payments/stripe"
+)
+
+func TestMemoryTransactionsDB(t *testing.T) {
+	ctx := testcontext.New(t)
+
+	transactions := stripe.NewMemoryTransactionsDB()
+
+	userID := testrand.UUID()
+	amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+	rate := decimal.NewFromFloat(1.5)
+
+	insertTestTransaction(ctx, t, transactions, "first", userID, coinpayments.StatusReceived, amount, amount)
+	insertTestTransaction(ctx, t, transactions, "second", userID, coinpayments.StatusReceived, amount, amount)
+	insertTestTransaction(ctx, t, transactions, "pending", userID, coinpayments.StatusPending, amount, amount)
+
+	_, err := transactions.TestInsert(ctx, stripe.Transaction{ID: "first", Amount: amount, Received: amount})
+	require.Error(t, err)
+
+	_, err = transactions.Get(ctx, "missing")
+	require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+
+	t.Run("pagination", func(t *testing.T) {
+		page, err := transactions.ListAccountPage(ctx, userID, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, page.Transactions, 2)
+		require.True(t, page.Next)
+		require.EqualValues(t, 2, page.NextOffset)
+
+		next, err := transactions.ListAccountPage(ctx, userID, page.NextOffset, 2)
+		require.NoError(t, err)
+		require.Len(t, next.Transactions, 1)
+		require.False(t, next.Next)
+
+		all, err := transactions.ListAccount(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, all, append(page.Transactions, next.Transactions...))
+
+		_, err = transactions.ListAccountPage(ctx, userID, 0, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("rates", func(t *testing.T) {
+		_, err := transactions.GetLockedRate(ctx, "first")
+		require.ErrorIs(t, err, stripe.ErrRateNotLocked)
+
+		require.NoError(t, transactions.TestLockRate(ctx, "first", rate))
+		require.Error(t, transactions.TestLockRate(ctx, "first", rate))
+
+		locked, err := transactions.GetLockedRate(ctx, "first")
+		require.NoError(t, err)
+		require.True(t, rate.Equal(locked))
+
+		rounded, err := transactions.GetLockedRateRounded(ctx, "first", 0)
+		require.NoError(t, err)
+		require.Equal(t, "2", rounded.String())
+	})
+
+	t.Run("apply balance intents", func(t *testing.T) {
+		require.ErrorIs(t, transactions.AddApplyBalanceIntent(ctx, "missing"), stripe.ErrTransactionNotFound)
+		require.NoError(t, transactions.AddApplyBalanceIntent(ctx, "first"))
+		require.NoError(t, transactions.AddApplyBalanceIntent(ctx, "second"))
+
+		count, err := transactions.CountUnapplied(ctx, time.Now())
+		require.NoError(t, err)
+		require.EqualValues(t, 2, count)
+
+		ids, err := transactions.FindUnappliedMissingRate(ctx, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, []coinpayments.TransactionID{"second"}, ids)
+
+		require.NoError(t, transactions.Consume(ctx, "first"))
+		require.ErrorIs(t, transactions.Consume(ctx, "first"), stripe.ErrTransactionConsumed)
+		require.ErrorIs(t, transactions.Reassign(ctx, "first", testrand.UUID()), stripe.ErrTransactionConsumed)
+
+		unapplied, consumed, oldest, err := transactions.ApplyBalanceIntentSummary(ctx, time.Now())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, unapplied)
+		require.EqualValues(t, 1, consumed)
+		require.False(t, oldest.IsZero())
+
+		page, err := transactions.ListUnappliedAfter(ctx, time.Time{}, "", 10, time.Now())
+		require.NoError(t, err)
+		require.Len(t, page.Transactions, 1)
+		require.Equal(t, coinpayments.TransactionID("second"), page.Transactions[0].ID)
+		require.False(t, page.Next)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		updated, err := transactions.MarkTimedOut(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, updated)
+
+		deleted, err := transactions.DeleteExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
+
+		_, err = transactions.Get(ctx, "pending")
+		require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+	})
+}
@@ -53,16 +53,17 @@ const (
 
 // Config stores needed information for payment service initialization.
 type Config struct {
-	StripeSecretKey        string `help:"stripe API secret key" default:""`
-	StripePublicKey        string `help:"stripe API public key" default:""`
-	StripeFreeTierCouponID string `help:"stripe free tier coupon ID" default:""`
-	AutoAdvance            bool   `help:"toggle autoadvance feature for invoice creation" default:"false"`
-	ListingLimit           int    `help:"sets the maximum amount of items before we start paging on requests" default:"100" hidden:"true"`
-	SkipEmptyInvoices      bool   `help:"if set, skips the creation of empty invoices for customers with zero usage for the billing period" default:"true"`
-	MaxParallelCalls       int    `help:"the maximum number of concurrent Stripe API calls in invoicing methods" default:"10"`
-	RemoveExpiredCredit    bool   `help:"whether to remove expired package credit or not" default:"true"`
-	UseIdempotency         bool   `help:"whether to use idempotency for create/update requests" default:"false"`
-	EnableFreeTrialLogic   bool   `help:"whether to use users upgrade time and skip free tier status in billing process" default:"false"`
+	StripeSecretKey        string        `help:"stripe API secret key" default:""`
+	StripePublicKey        string        `help:"stripe API public key" default:""`
+	StripeFreeTierCouponID string        `help:"stripe free tier coupon ID" default:""`
+	AutoAdvance            bool          `help:"toggle autoadvance feature for invoice creation" default:"false"`
+	ListingLimit           int           `help:"sets the maximum amount of items before we start paging on requests" default:"100" hidden:"true"`
+	SkipEmptyInvoices      bool          `help:"if set, skips the creation of empty invoices for customers with zero usage for the billing period" default:"true"`
+	MaxParallelCalls       int           `help:"the maximum number of concurrent Stripe API calls in invoicing methods" default:"10"`
+	RemoveExpiredCredit    bool          `help:"whether to remove expired package credit or not" default:"true"`
+	UseIdempotency         bool          `help:"whether to use idempotency for create/update requests" default:"false"`
+	EnableFreeTrialLogic   bool          `help:"whether to use users upgrade time and skip free tier status in billing process" default:"false"`
+	UnappliedStatInterval  time.Duration `help:"how frequently the number of unapplied coinpayments transactions is reported" releaseDefault:"1h" devDefault:"1m0s" testDefault:"$TESTINTERVAL"`
 	Retries                RetryConfig
 }
 
@@ -57,7 +57,7 @@ func (tokens *storjTokens) ListTransactionInfos(ctx context.Context, userID uuid
 			status = payments.TransactionStatusPending
 		case coinpayments.StatusReceived:
 			status = payments.TransactionStatusPaid
-		case coinpayments.StatusCancelled:
+		case coinpayments.StatusCancelled, coinpayments.StatusTimedOut:
 			status = payments.TransactionStatusCancelled
 		default:
 			// unknown
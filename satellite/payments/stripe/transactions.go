@@ -14,15 +14,79 @@ import (
 	"storj.io/storj/satellite/payments/coinpayments"
 )
 
+var (
+	// ErrTransactionNotFound is returned when coinpayments transaction doesn't exist.
+	ErrTransactionNotFound = Error.New("transaction doesn't exist")
+	// ErrTransactionConsumed is returned when the balance of the coinpayments transaction was already applied.
+	ErrTransactionConsumed = Error.New("transaction balance was already applied")
+	// ErrRateNotLocked is returned when no conversion rate was locked for the coinpayments transaction.
+	ErrRateNotLocked = Error.New("conversion rate is not locked")
+)
+
 // TransactionsDB is an interface which defines functionality
 // of DB which stores coinpayments transactions.
 //
 // architecture: Database
 type TransactionsDB interface {
-	// GetLockedRate returns locked conversion rate for transaction or error if non exists.
+	// GetLockedRate returns locked conversion rate for transaction or ErrRateNotLocked if non exists.
 	GetLockedRate(ctx context.Context, id coinpayments.TransactionID) (decimal.Decimal, error)
-	// ListAccount returns all transaction for specific user.
+	// GetLockedRateWithTime returns locked conversion rate for transaction and the time it was locked at
+	// or ErrRateNotLocked if non exists.
+	GetLockedRateWithTime(ctx context.Context, id coinpayments.TransactionID) (decimal.Decimal, time.Time, error)
+	// GetLockedRateRounded returns locked conversion rate for transaction rounded to the given decimal places
+	// or ErrRateNotLocked if non exists. The rate is stored as a float, GetLockedRate should be used for exact
+	// arithmetic with the stored value.
+	GetLockedRateRounded(ctx context.Context, id coinpayments.TransactionID, places int32) (decimal.Decimal, error)
+	// Get returns transaction with the given id.
+	Get(ctx context.Context, id coinpayments.TransactionID) (*Transaction, error)
+	// ListAccount returns all transaction for specific user. It loads the whole history of the user
+	// into memory, use ListAccountPage for accounts with many transactions.
 	ListAccount(ctx context.Context, userID uuid.UUID) ([]Transaction, error)
+	// ListAccountPage returns a page of transactions for specific user, ordered by creation time descending.
+	// Limit must be positive.
+	ListAccountPage(ctx context.Context, userID uuid.UUID, offset int64, limit int) (TransactionsPage, error)
+	// ListPartialPayments returns received transactions created before the given time, which received
+	// less than the requested amount, ordered by creation time descending. Limit must be positive.
+	ListPartialPayments(ctx context.Context, before time.Time, limit int) (TransactionsPage, error)
+	// ExportAccount returns all transactions for specific user in the data export format.
+	ExportAccount(ctx context.Context, userID uuid.UUID) ([]TransactionExport, error)
+	// ListAccountFiltered returns transactions for specific user which have one of the given
+	// statuses and were created at or after the given time. Empty statuses matches all statuses.
+	ListAccountFiltered(ctx context.Context, userID uuid.UUID, statuses []coinpayments.Status, after time.Time) ([]Transaction, error)
+	// TotalReceived returns the total amount received in the given currency
+	// by the user's transactions which have at least the received status.
+	TotalReceived(ctx context.Context, userID uuid.UUID, curr *currency.Currency) (currency.Amount, error)
+	// InsertWithRate inserts new coinpayments transaction and locks its conversion rate atomically.
+	InsertWithRate(ctx context.Context, tx Transaction, rate decimal.Decimal) (*Transaction, error)
+	// LockRates locks conversion rates for multiple transactions at once.
+	LockRates(ctx context.Context, rates map[coinpayments.TransactionID]decimal.Decimal) error
+	// DeleteExpired deletes pending and timed out transactions which timed out before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (deleted int64, err error)
+	// MarkTimedOut changes the status of the pending transactions, which timed out before the given time, to timed out.
+	MarkTimedOut(ctx context.Context, before time.Time) (updated int64, err error)
+	// Reassign moves the transaction to the given account. It fails with ErrTransactionConsumed
+	// when the balance of the transaction was already applied.
+	Reassign(ctx context.Context, id coinpayments.TransactionID, newAccountID uuid.UUID) error
+	// CountUnapplied returns the number of received transactions created before the given time
+	// which have an unapplied balance intent.
+	CountUnapplied(ctx context.Context, before time.Time) (int64, error)
+	// ListAccountsWithUnapplied returns the accounts with the most received transactions created before
+	// the given time which have an unapplied balance intent, ordered by the count descending.
+	ListAccountsWithUnapplied(ctx context.Context, before time.Time, limit int) ([]UnappliedAccount, error)
+	// FindUnappliedMissingRate returns the ids of the received transactions created before the given time
+	// which have an unapplied balance intent, but no locked conversion rate.
+	FindUnappliedMissingRate(ctx context.Context, before time.Time) ([]coinpayments.TransactionID, error)
+	// ApplyBalanceIntentSummary returns the number of unapplied and consumed apply balance intents
+	// created before the given time, and the creation time of the oldest unapplied one.
+	ApplyBalanceIntentSummary(ctx context.Context, before time.Time) (unapplied, consumed int64, oldestUnapplied time.Time, err error)
+	// ListUnappliedAfter returns a page of received transactions created before the given time
+	// which have an unapplied balance intent, starting after the given creation time and id.
+	// Limit must be positive.
+	ListUnappliedAfter(ctx context.Context, afterCreatedAt time.Time, afterID coinpayments.TransactionID, limit int, before time.Time) (UnappliedTransactionsPage, error)
+	// RescaleAmounts changes the currency of the given transactions from one currency to another and
+	// rescales the amounts to the decimal places of the new currency. All the transactions must be in the
+	// from currency, so already rescaled transactions are rejected.
+	RescaleAmounts(ctx context.Context, from, to *currency.Currency, ids []coinpayments.TransactionID) error
 	// TestInsert inserts new coinpayments transaction into DB.
 	TestInsert(ctx context.Context, tx Transaction) (time.Time, error)
 	// TestLockRate locks conversion rate for transaction.
@@ -37,7 +101,60 @@ type Transaction struct {
 	Amount    currency.Amount
 	Received  currency.Amount
 	Status    coinpayments.Status
+	Confirms  int
 	Key       string
 	Timeout   time.Duration
 	CreatedAt time.Time
 }
+
+// TransactionExport is the flattened representation of a coinpayments transaction
+// used by data exports. Amounts are formatted with FormatAmount in the
+// transaction currency and the creation time is in RFC 3339 format.
+type TransactionExport struct {
+	ID        string
+	AccountID string
+	Address   string
+	Amount    string
+	Received  string
+	Currency  string
+	Status    string
+	CreatedAt string
+}
+
+// Export converts the transaction to the data export format.
+func (tx Transaction) Export() TransactionExport {
+	return TransactionExport{
+		ID:        tx.ID.String(),
+		AccountID: tx.AccountID.String(),
+		Address:   tx.Address,
+		Amount:    FormatAmount(tx.Amount),
+		Received:  FormatAmount(tx.Received),
+		Currency:  tx.Amount.Currency().Symbol(),
+		Status:    tx.Status.String(),
+		CreatedAt: tx.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// TransactionsPage holds a page of transactions and
+// indicates if there are more transactions to fetch.
+type TransactionsPage struct {
+	Transactions []Transaction
+	Next         bool
+	NextOffset   int64
+}
+
+// UnappliedAccount holds the number of unapplied transactions of an account.
+type UnappliedAccount struct {
+	AccountID uuid.UUID
+	Count     int64
+}
+
+// UnappliedTransactionsPage holds a page of unapplied transactions,
+// indicates if there is more data available and provides
+// the creation time and id of the last transaction to continue from.
+type UnappliedTransactionsPage struct {
+	Transactions  []Transaction
+	Next          bool
+	LastCreatedAt time.Time
+	LastID        coinpayments.TransactionID
+}
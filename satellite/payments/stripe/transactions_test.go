@@ -5,6 +5,7 @@ package stripe_test
 
 import (
 	"encoding/base64"
+	"strconv"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ func TestTransactionsDB(t *testing.T) {
 			Amount:    amount,
 			Received:  received,
 			Status:    coinpayments.StatusPending,
+			Confirms:  3,
 			Key:       "testKey",
 			Timeout:   time.Second * 60,
 		}
@@ -53,9 +55,682 @@ func TestTransactionsDB(t *testing.T) {
 			require.Len(t, txs, 1)
 			compareTransactions(t, createTx, txs[0])
 		})
+
+		t.Run("insert with currency", func(t *testing.T) {
+			usdAmount, err := currency.AmountFromString("2.05", currency.USDollars)
+			require.NoError(t, err)
+			usdReceived, err := currency.AmountFromString("1.5", currency.USDollars)
+			require.NoError(t, err)
+
+			usdTx := createTx
+			usdTx.ID = "testUSDID"
+			usdTx.AccountID = testrand.UUID()
+			usdTx.Amount = usdAmount
+			usdTx.Received = usdReceived
+
+			_, err = transactions.TestInsert(ctx, usdTx)
+			require.NoError(t, err)
+			txs, err := transactions.ListAccount(ctx, usdTx.AccountID)
+			require.NoError(t, err)
+			require.Len(t, txs, 1)
+			compareTransactions(t, usdTx, txs[0])
+			require.Equal(t, currency.USDollars, txs[0].Amount.Currency())
+			require.Equal(t, currency.USDollars, txs[0].Received.Currency())
+		})
+
+		t.Run("insert with bitcoin", func(t *testing.T) {
+			btcTx := createTx
+			btcTx.ID = "testBTCID"
+			btcTx.AccountID = testrand.UUID()
+			btcTx.Amount = currency.AmountFromBaseUnits(150000000, currency.Bitcoin)
+			btcTx.Received = currency.AmountFromBaseUnits(50000000, currency.Bitcoin)
+
+			_, err := transactions.TestInsert(ctx, btcTx)
+			require.NoError(t, err)
+			txs, err := transactions.ListAccount(ctx, btcTx.AccountID)
+			require.NoError(t, err)
+			require.Len(t, txs, 1)
+			compareTransactions(t, btcTx, txs[0])
+			require.Equal(t, currency.Bitcoin, txs[0].Amount.Currency())
+
+			tx, err := transactions.Get(ctx, btcTx.ID)
+			require.NoError(t, err)
+			compareTransactions(t, btcTx, *tx)
+		})
+
+		t.Run("get", func(t *testing.T) {
+			tx, err := transactions.Get(ctx, createTx.ID)
+			require.NoError(t, err)
+			compareTransactions(t, createTx, *tx)
+
+			_, err = transactions.Get(ctx, "missingID")
+			require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+		})
+	})
+}
+
+func TestTransactionsDBListAccountFiltered(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		amount, err := currency.AmountFromString("2", currency.StorjToken)
+		require.NoError(t, err)
+		userID := testrand.UUID()
+
+		statuses := []coinpayments.Status{
+			coinpayments.StatusPending,
+			coinpayments.StatusReceived,
+			coinpayments.StatusCompleted,
+			coinpayments.StatusCancelled,
+		}
+
+		txs := make(map[coinpayments.TransactionID]stripe.Transaction)
+		for i, status := range statuses {
+			tx := insertTestTransaction(ctx, t, transactions, coinpayments.TransactionID("testID"+strconv.Itoa(i)), userID, status, amount, currency.AmountFromBaseUnits(0, currency.StorjToken))
+			txs[tx.ID] = tx
+		}
+
+		all, err := transactions.ListAccountFiltered(ctx, userID, nil, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, all, len(statuses))
+
+		filtered, err := transactions.ListAccountFiltered(ctx, userID, []coinpayments.Status{coinpayments.StatusPending, coinpayments.StatusReceived}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, filtered, 2)
+		for _, tx := range filtered {
+			require.Contains(t, []coinpayments.Status{coinpayments.StatusPending, coinpayments.StatusReceived}, tx.Status)
+			compareTransactions(t, txs[tx.ID], tx)
+		}
+
+		future, err := transactions.ListAccountFiltered(ctx, userID, nil, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, future)
+	})
+}
+
+func TestTransactionsDBTotalReceived(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+
+		total, err := transactions.TotalReceived(ctx, userID, currency.StorjToken)
+		require.NoError(t, err)
+		require.Equal(t, currency.AmountFromBaseUnits(0, currency.StorjToken), total)
+
+		for i, tx := range []struct {
+			status   coinpayments.Status
+			curr     *currency.Currency
+			received int64
+		}{
+			{coinpayments.StatusPending, currency.StorjToken, 1},
+			{coinpayments.StatusReceived, currency.StorjToken, 10},
+			{coinpayments.StatusCompleted, currency.StorjToken, 100},
+			{coinpayments.StatusCancelled, currency.StorjToken, 1000},
+			{coinpayments.StatusCompleted, currency.USDollars, 10000},
+		} {
+			received := currency.AmountFromBaseUnits(tx.received, tx.curr)
+			insertTestTransaction(ctx, t, transactions, coinpayments.TransactionID("testID"+strconv.Itoa(i)), userID, tx.status, received, received)
+		}
+
+		total, err = transactions.TotalReceived(ctx, userID, currency.StorjToken)
+		require.NoError(t, err)
+		require.Equal(t, currency.AmountFromBaseUnits(110, currency.StorjToken), total)
+
+		total, err = transactions.TotalReceived(ctx, userID, currency.USDollars)
+		require.NoError(t, err)
+		require.Equal(t, currency.AmountFromBaseUnits(10000, currency.USDollars), total)
+
+		_, err = transactions.TotalReceived(ctx, userID, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestTransactionsDBDeleteExpired(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		insertTestTransaction(ctx, t, transactions, "pending", userID, coinpayments.StatusPending, amount, amount)
+		insertTestTransaction(ctx, t, transactions, "received", userID, coinpayments.StatusReceived, amount, amount)
+		insertTestTransaction(ctx, t, transactions, "pendingWithIntent", userID, coinpayments.StatusPending, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "pendingWithIntent", 0)
+
+		// nothing has timed out yet.
+		deleted, err := transactions.DeleteExpired(ctx, time.Now())
+		require.NoError(t, err)
+		require.Zero(t, deleted)
+
+		deleted, err = transactions.DeleteExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
+
+		_, err = transactions.Get(ctx, "pending")
+		require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+
+		_, err = transactions.Get(ctx, "received")
+		require.NoError(t, err)
+
+		_, err = transactions.Get(ctx, "pendingWithIntent")
+		require.NoError(t, err)
+	})
+}
+
+func TestTransactionsDBTimeout(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		tx := stripe.Transaction{
+			ID:        "tx_id",
+			AccountID: testrand.UUID(),
+			Address:   "testAddress",
+			Amount:    currency.AmountFromBaseUnits(100, currency.StorjToken),
+			Received:  currency.AmountFromBaseUnits(0, currency.StorjToken),
+			Status:    coinpayments.StatusPending,
+			Key:       "testKey",
+			Timeout:   500 * time.Millisecond,
+		}
+
+		// the timeout is stored with second precision.
+		_, err := transactions.TestInsert(ctx, tx)
+		require.Error(t, err)
+		_, err = transactions.InsertWithRate(ctx, tx, decimal.NewFromFloat(1.5))
+		require.Error(t, err)
+
+		_, err = transactions.Get(ctx, tx.ID)
+		require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+
+		tx.Timeout = 90 * time.Second
+		_, err = transactions.TestInsert(ctx, tx)
+		require.NoError(t, err)
+
+		inserted, err := transactions.Get(ctx, tx.ID)
+		require.NoError(t, err)
+		require.Equal(t, tx.Timeout, inserted.Timeout)
+	})
+}
+
+func TestTransactionsDBListAccountPage(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		const transactionCount = 5
+		for i := 0; i < transactionCount; i++ {
+			insertTestTransaction(ctx, t, transactions, coinpayments.TransactionID("tx_"+strconv.Itoa(i)), userID, coinpayments.StatusPending, amount, amount)
+		}
+		insertTestTransaction(ctx, t, transactions, "other", testrand.UUID(), coinpayments.StatusPending, amount, amount)
+
+		expected, err := transactions.ListAccount(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, expected, transactionCount)
+
+		var listed []stripe.Transaction
+		page := stripe.TransactionsPage{Next: true}
+		for page.Next {
+			page, err = transactions.ListAccountPage(ctx, userID, page.NextOffset, 2)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Transactions), 2)
+			listed = append(listed, page.Transactions...)
+		}
+
+		require.Len(t, listed, transactionCount)
+		for _, tx := range listed {
+			require.Equal(t, userID, tx.AccountID)
+		}
+		for i := 1; i < len(listed); i++ {
+			require.False(t, listed[i].CreatedAt.After(listed[i-1].CreatedAt))
+		}
+		require.ElementsMatch(t, expected, listed)
+
+		_, err = transactions.ListAccountPage(ctx, userID, 0, 0)
+		require.Error(t, err)
+	})
+}
+
+func TestTransactionsDBExportAccount(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(1234500000, currency.StorjToken)
+		received := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		tx := insertTestTransaction(ctx, t, transactions, "export", userID, coinpayments.StatusReceived, amount, received)
+		insertTestTransaction(ctx, t, transactions, "other", testrand.UUID(), coinpayments.StatusPending, amount, received)
+
+		exports, err := transactions.ExportAccount(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, []stripe.TransactionExport{{
+			ID:        "export",
+			AccountID: userID.String(),
+			Address:   "testAddress",
+			Amount:    "12.345",
+			Received:  "0.000001",
+			Currency:  currency.StorjToken.Symbol(),
+			Status:    "received",
+			CreatedAt: tx.CreatedAt.UTC().Format(time.RFC3339),
+		}}, exports)
+
+		exports, err = transactions.ExportAccount(ctx, testrand.UUID())
+		require.NoError(t, err)
+		require.Empty(t, exports)
+	})
+}
+
+func TestTransactionsDBMarkTimedOut(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		insertTestTransaction(ctx, t, transactions, "pending", userID, coinpayments.StatusPending, amount, amount)
+		insertTestTransaction(ctx, t, transactions, "received", userID, coinpayments.StatusReceived, amount, amount)
+
+		// nothing has timed out yet.
+		updated, err := transactions.MarkTimedOut(ctx, time.Now())
+		require.NoError(t, err)
+		require.Zero(t, updated)
+
+		updated, err = transactions.MarkTimedOut(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, updated)
+
+		tx, err := transactions.Get(ctx, "pending")
+		require.NoError(t, err)
+		require.Equal(t, coinpayments.StatusTimedOut, tx.Status)
+
+		tx, err = transactions.Get(ctx, "received")
+		require.NoError(t, err)
+		require.Equal(t, coinpayments.StatusReceived, tx.Status)
+
+		pending, err := transactions.ListAccountFiltered(ctx, userID, []coinpayments.Status{coinpayments.StatusPending}, time.Time{})
+		require.NoError(t, err)
+		require.Empty(t, pending)
+
+		// timed out transactions are deleted as expired.
+		deleted, err := transactions.DeleteExpired(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 1, deleted)
+
+		_, err = transactions.Get(ctx, "pending")
+		require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+	})
+}
+
+func TestTransactionsDBReassign(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID, newUserID := testrand.UUID(), testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		insertTestTransaction(ctx, t, transactions, "unapplied", userID, coinpayments.StatusReceived, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "unapplied", 0)
+		insertTestTransaction(ctx, t, transactions, "consumed", userID, coinpayments.StatusReceived, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "consumed", 1)
+
+		t.Run("reassign", func(t *testing.T) {
+			require.NoError(t, transactions.Reassign(ctx, "unapplied", newUserID))
+
+			tx, err := transactions.Get(ctx, "unapplied")
+			require.NoError(t, err)
+			require.Equal(t, newUserID, tx.AccountID)
+		})
+
+		t.Run("consumed", func(t *testing.T) {
+			err := transactions.Reassign(ctx, "consumed", newUserID)
+			require.ErrorIs(t, err, stripe.ErrTransactionConsumed)
+
+			tx, err := transactions.Get(ctx, "consumed")
+			require.NoError(t, err)
+			require.Equal(t, userID, tx.AccountID)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			err := transactions.Reassign(ctx, "missing", newUserID)
+			require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+		})
 	})
 }
 
+func TestTransactionsDBCountUnapplied(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		for _, tx := range []struct {
+			id     coinpayments.TransactionID
+			status coinpayments.Status
+			state  int
+		}{
+			{"pending", coinpayments.StatusPending, 0},
+			{"received", coinpayments.StatusReceived, 0},
+			{"completed", coinpayments.StatusCompleted, 0},
+			{"consumed", coinpayments.StatusCompleted, 1},
+		} {
+			insertTestTransaction(ctx, t, transactions, tx.id, userID, tx.status, amount, amount)
+			insertApplyBalanceIntent(ctx, t, db, tx.id, tx.state)
+		}
+
+		count, err := transactions.CountUnapplied(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 2, count)
+
+		count, err = transactions.CountUnapplied(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
+
+func TestTransactionsDBListAccountsWithUnapplied(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		firstUserID, secondUserID, thirdUserID := testrand.UUID(), testrand.UUID(), testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		for _, tx := range []struct {
+			id     coinpayments.TransactionID
+			userID uuid.UUID
+			status coinpayments.Status
+			state  int
+		}{
+			{"first1", firstUserID, coinpayments.StatusReceived, 0},
+			{"second1", secondUserID, coinpayments.StatusReceived, 0},
+			{"second2", secondUserID, coinpayments.StatusCompleted, 0},
+			{"second3", secondUserID, coinpayments.StatusCompleted, 1},
+			{"third1", thirdUserID, coinpayments.StatusPending, 0},
+			{"third2", thirdUserID, coinpayments.StatusCompleted, 1},
+		} {
+			insertTestTransaction(ctx, t, transactions, tx.id, tx.userID, tx.status, amount, amount)
+			insertApplyBalanceIntent(ctx, t, db, tx.id, tx.state)
+		}
+
+		accounts, err := transactions.ListAccountsWithUnapplied(ctx, time.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Equal(t, []stripe.UnappliedAccount{
+			{AccountID: secondUserID, Count: 2},
+			{AccountID: firstUserID, Count: 1},
+		}, accounts)
+
+		accounts, err = transactions.ListAccountsWithUnapplied(ctx, time.Now().Add(time.Hour), 1)
+		require.NoError(t, err)
+		require.Equal(t, []stripe.UnappliedAccount{{AccountID: secondUserID, Count: 2}}, accounts)
+
+		accounts, err = transactions.ListAccountsWithUnapplied(ctx, time.Now().Add(-time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, accounts)
+
+		_, err = transactions.ListAccountsWithUnapplied(ctx, time.Now(), 0)
+		require.Error(t, err)
+	})
+}
+
+func TestTransactionsDBFindUnappliedMissingRate(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+		rate := decimal.NewFromFloat(1.5)
+
+		// unapplied without rate.
+		insertTestTransaction(ctx, t, transactions, "missing", userID, coinpayments.StatusReceived, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "missing", 0)
+
+		// unapplied with rate.
+		insertTestTransaction(ctx, t, transactions, "locked", userID, coinpayments.StatusReceived, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "locked", 0)
+		require.NoError(t, transactions.TestLockRate(ctx, "locked", rate))
+
+		// consumed without rate.
+		insertTestTransaction(ctx, t, transactions, "consumed", userID, coinpayments.StatusReceived, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "consumed", 1)
+
+		// pending without rate.
+		insertTestTransaction(ctx, t, transactions, "pending", userID, coinpayments.StatusPending, amount, amount)
+		insertApplyBalanceIntent(ctx, t, db, "pending", 0)
+
+		// received without intent.
+		insertTestTransaction(ctx, t, transactions, "noIntent", userID, coinpayments.StatusReceived, amount, amount)
+
+		ids, err := transactions.FindUnappliedMissingRate(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, []coinpayments.TransactionID{"missing"}, ids)
+
+		ids, err = transactions.FindUnappliedMissingRate(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+}
+
+func TestTransactionsDBApplyBalanceIntentSummary(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		unapplied, consumed, oldest, err := transactions.ApplyBalanceIntentSummary(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, unapplied)
+		require.Zero(t, consumed)
+		require.True(t, oldest.IsZero())
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		for _, tx := range []struct {
+			id    coinpayments.TransactionID
+			state int
+		}{
+			{"unapplied1", 0},
+			{"unapplied2", 0},
+			{"consumed1", 1},
+			{"consumed2", 1},
+			{"consumed3", 1},
+		} {
+			insertTestTransaction(ctx, t, transactions, tx.id, userID, coinpayments.StatusCompleted, amount, amount)
+			insertApplyBalanceIntent(ctx, t, db, tx.id, tx.state)
+		}
+
+		var firstCreatedAt time.Time
+		err = db.Testing().RawDB().QueryRowContext(ctx,
+			"SELECT created_at FROM stripecoinpayments_apply_balance_intents WHERE tx_id = $1", "unapplied1",
+		).Scan(&firstCreatedAt)
+		require.NoError(t, err)
+
+		unapplied, consumed, oldest, err = transactions.ApplyBalanceIntentSummary(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 2, unapplied)
+		require.EqualValues(t, 3, consumed)
+		require.WithinDuration(t, firstCreatedAt, oldest, time.Microsecond)
+
+		unapplied, consumed, oldest, err = transactions.ApplyBalanceIntentSummary(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, unapplied)
+		require.Zero(t, consumed)
+		require.True(t, oldest.IsZero())
+	})
+}
+
+func TestTransactionsDBListUnappliedAfter(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		const (
+			limit            = 3
+			transactionCount = limit*2 + 1
+		)
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+
+		var before time.Time
+		expected := make(map[coinpayments.TransactionID]bool)
+		for i := 0; i < transactionCount; i++ {
+			id := coinpayments.TransactionID("testID" + strconv.Itoa(i))
+			tx := insertTestTransaction(ctx, t, transactions, id, userID, coinpayments.StatusReceived, amount, amount)
+			insertApplyBalanceIntent(ctx, t, db, id, 0)
+			expected[id] = true
+			before = tx.CreatedAt
+		}
+
+		// transactions created after before must not be listed.
+		for i := 0; i < limit; i++ {
+			id := coinpayments.TransactionID("lateID" + strconv.Itoa(i))
+			tx := insertTestTransaction(ctx, t, transactions, id, userID, coinpayments.StatusReceived, amount, amount)
+			require.True(t, tx.CreatedAt.After(before))
+			insertApplyBalanceIntent(ctx, t, db, id, 0)
+		}
+
+		_, err := transactions.ListUnappliedAfter(ctx, time.Time{}, "", 0, before)
+		require.Error(t, err)
+
+		var afterCreatedAt time.Time
+		var afterID coinpayments.TransactionID
+		var listed []stripe.Transaction
+		for {
+			page, err := transactions.ListUnappliedAfter(ctx, afterCreatedAt, afterID, limit, before)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Transactions), limit)
+
+			listed = append(listed, page.Transactions...)
+
+			if !page.Next {
+				break
+			}
+			afterCreatedAt, afterID = page.LastCreatedAt, page.LastID
+		}
+
+		listedIDs := make(map[coinpayments.TransactionID]bool)
+		for i, tx := range listed {
+			require.False(t, listedIDs[tx.ID], "transaction %s listed twice", tx.ID)
+			listedIDs[tx.ID] = true
+
+			if i > 0 {
+				prev := listed[i-1]
+				require.False(t, tx.CreatedAt.After(prev.CreatedAt), "transactions are not ordered by creation time")
+				if tx.CreatedAt.Equal(prev.CreatedAt) {
+					require.Less(t, tx.ID, prev.ID, "transactions are not ordered by id")
+				}
+			}
+		}
+		require.Equal(t, expected, listedIDs)
+	})
+}
+
+func TestTransactionsDBListPartialPayments(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(100, currency.StorjToken)
+		partial := currency.AmountFromBaseUnits(50, currency.StorjToken)
+
+		first := insertTestTransaction(ctx, t, transactions, "partial1", userID, coinpayments.StatusReceived, amount, partial)
+		second := insertTestTransaction(ctx, t, transactions, "partial2", userID, coinpayments.StatusReceived, amount, partial)
+		insertTestTransaction(ctx, t, transactions, "full", userID, coinpayments.StatusReceived, amount, amount)
+		insertTestTransaction(ctx, t, transactions, "pending", userID, coinpayments.StatusPending, amount, partial)
+		insertTestTransaction(ctx, t, transactions, "completed", userID, coinpayments.StatusCompleted, amount, partial)
+
+		page, err := transactions.ListPartialPayments(ctx, time.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.False(t, page.Next)
+		require.Len(t, page.Transactions, 2)
+		require.Equal(t, second.ID, page.Transactions[0].ID)
+		require.Equal(t, first.ID, page.Transactions[1].ID)
+
+		page, err = transactions.ListPartialPayments(ctx, time.Now().Add(time.Hour), 1)
+		require.NoError(t, err)
+		require.True(t, page.Next)
+		require.Len(t, page.Transactions, 1)
+
+		page, err = transactions.ListPartialPayments(ctx, time.Now().Add(-time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, page.Transactions)
+
+		_, err = transactions.ListPartialPayments(ctx, time.Now(), 0)
+		require.Error(t, err)
+	})
+}
+
+func TestTransactionsDBRescaleAmounts(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		userID := testrand.UUID()
+		amount := currency.AmountFromBaseUnits(150000000, currency.StorjToken)
+		received := currency.AmountFromBaseUnits(50000000, currency.StorjToken)
+		inexact := currency.AmountFromBaseUnits(1, currency.StorjToken)
+
+		insertTestTransaction(ctx, t, transactions, "first", userID, coinpayments.StatusReceived, amount, received)
+		insertTestTransaction(ctx, t, transactions, "second", userID, coinpayments.StatusReceived, amount, amount)
+		insertTestTransaction(ctx, t, transactions, "inexact", userID, coinpayments.StatusReceived, inexact, inexact)
+
+		err := transactions.RescaleAmounts(ctx, currency.StorjToken, currency.USDollars, []coinpayments.TransactionID{"second", "inexact"})
+		require.Error(t, err)
+
+		err = transactions.RescaleAmounts(ctx, currency.StorjToken, currency.USDollars, []coinpayments.TransactionID{"first"})
+		require.NoError(t, err)
+
+		tx, err := transactions.Get(ctx, "first")
+		require.NoError(t, err)
+		require.Equal(t, currency.AmountFromBaseUnits(150, currency.USDollars), tx.Amount)
+		require.Equal(t, currency.AmountFromBaseUnits(50, currency.USDollars), tx.Received)
+
+		// the transactions aren't changed when one of them can't be rescaled.
+		tx, err = transactions.Get(ctx, "second")
+		require.NoError(t, err)
+		require.Equal(t, amount, tx.Amount)
+
+		// rescaling the same transaction again is rejected.
+		err = transactions.RescaleAmounts(ctx, currency.StorjToken, currency.USDollars, []coinpayments.TransactionID{"first"})
+		require.Error(t, err)
+
+		err = transactions.RescaleAmounts(ctx, currency.StorjToken, currency.StorjToken, []coinpayments.TransactionID{"second"})
+		require.Error(t, err)
+	})
+}
+
+// insertTestTransaction inserts a coinpayments transaction with the given
+// status and amounts for the user.
+func insertTestTransaction(ctx *testcontext.Context, t *testing.T, transactions stripe.TransactionsDB, id coinpayments.TransactionID, userID uuid.UUID, status coinpayments.Status, amount, received currency.Amount) stripe.Transaction {
+	t.Helper()
+
+	tx := stripe.Transaction{
+		ID:        id,
+		AccountID: userID,
+		Address:   "testAddress",
+		Amount:    amount,
+		Received:  received,
+		Status:    status,
+		Key:       "testKey",
+		Timeout:   time.Minute,
+	}
+	createdAt, err := transactions.TestInsert(ctx, tx)
+	require.NoError(t, err)
+	tx.CreatedAt = createdAt
+	return tx
+}
+
+// insertApplyBalanceIntent inserts an apply balance intent in the given state
+// for the transaction.
+func insertApplyBalanceIntent(ctx *testcontext.Context, t *testing.T, db satellite.DB, txID coinpayments.TransactionID, state int) {
+	t.Helper()
+
+	_, err := db.Testing().RawDB().ExecContext(ctx,
+		"INSERT INTO stripecoinpayments_apply_balance_intents (tx_id, state, created_at) VALUES ($1, $2, now())",
+		txID, state,
+	)
+	require.NoError(t, err)
+}
+
 func requireSaneTimestamp(t *testing.T, when time.Time) {
 	// ensure time value is sane. I apologize to you people of the future when this starts breaking
 	require.Truef(t, when.After(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)),
@@ -135,6 +810,108 @@ func TestTransactionsDBRates(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, val, rate)
+
+		rate, lockedAt, err := transactions.GetLockedRateWithTime(ctx, txID)
+		require.NoError(t, err)
+		assert.Equal(t, val, rate)
+		requireSaneTimestamp(t, lockedAt)
+
+		_, err = transactions.GetLockedRate(ctx, "unknown_tx_id")
+		require.ErrorIs(t, err, stripe.ErrRateNotLocked)
+
+		_, _, err = transactions.GetLockedRateWithTime(ctx, "unknown_tx_id")
+		require.ErrorIs(t, err, stripe.ErrRateNotLocked)
+
+		_, err = transactions.GetLockedRateRounded(ctx, "unknown_tx_id", 8)
+		require.ErrorIs(t, err, stripe.ErrRateNotLocked)
+	})
+}
+
+func TestTransactionsDBGetLockedRateRounded(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		// 0.1 + 0.2 is 0.30000000000000004 as a float.
+		a, b := 0.1, 0.2
+		const txID = "tx_id"
+		require.NoError(t, transactions.TestLockRate(ctx, txID, decimal.NewFromFloat(a+b)))
+
+		raw, err := transactions.GetLockedRate(ctx, txID)
+		require.NoError(t, err)
+		require.Equal(t, "0.30000000000000004", raw.String())
+
+		rounded, err := transactions.GetLockedRateRounded(ctx, txID, 8)
+		require.NoError(t, err)
+		require.Equal(t, "0.3", rounded.String())
+	})
+}
+
+func TestTransactionsDBInsertWithRate(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		tx := stripe.Transaction{
+			ID:        "tx_id",
+			AccountID: testrand.UUID(),
+			Address:   "testAddress",
+			Amount:    currency.AmountFromBaseUnits(1000, currency.StorjToken),
+			Received:  currency.AmountFromBaseUnits(0, currency.StorjToken),
+			Status:    coinpayments.StatusPending,
+			Key:       "testKey",
+			Timeout:   time.Minute,
+		}
+		rate := decimal.NewFromFloat(1.5)
+
+		inserted, err := transactions.InsertWithRate(ctx, tx, rate)
+		require.NoError(t, err)
+		compareTransactions(t, tx, *inserted)
+
+		lockedRate, err := transactions.GetLockedRate(ctx, tx.ID)
+		require.NoError(t, err)
+		assert.True(t, rate.Equal(lockedRate))
+
+		// the rate of an already locked transaction can't be locked again,
+		// so the new transaction isn't inserted either.
+		err = transactions.TestLockRate(ctx, "tx_id_2", rate)
+		require.NoError(t, err)
+
+		tx.ID = "tx_id_2"
+		_, err = transactions.InsertWithRate(ctx, tx, rate)
+		require.Error(t, err)
+
+		_, err = transactions.Get(ctx, tx.ID)
+		require.ErrorIs(t, err, stripe.ErrTransactionNotFound)
+	})
+}
+
+func TestTransactionsDBLockRates(t *testing.T) {
+	satellitedbtest.Run(t, func(ctx *testcontext.Context, t *testing.T, db satellite.DB) {
+		transactions := db.StripeCoinPayments().Transactions()
+
+		rates := map[coinpayments.TransactionID]decimal.Decimal{
+			"tx_id_1": decimal.NewFromFloat(1.5),
+			"tx_id_2": decimal.NewFromFloat(0.25),
+			"tx_id_3": decimal.NewFromFloat(4),
+		}
+
+		err := transactions.LockRates(ctx, rates)
+		require.NoError(t, err)
+
+		for id, expected := range rates {
+			rate, err := transactions.GetLockedRate(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, expected.Equal(rate))
+		}
+
+		// locking an already locked rate fails the whole batch.
+		err = transactions.LockRates(ctx, map[coinpayments.TransactionID]decimal.Decimal{
+			"tx_id_1": decimal.NewFromFloat(2),
+			"tx_id_4": decimal.NewFromFloat(3),
+		})
+		require.Error(t, err)
+
+		_, err = transactions.GetLockedRate(ctx, "tx_id_4")
+		require.ErrorIs(t, err, stripe.ErrRateNotLocked)
 	})
 }
 
@@ -148,6 +925,7 @@ func compareTransactions(t *testing.T, exp, act stripe.Transaction) {
 	assert.Equal(t, exp.Amount, act.Amount)
 	assert.Equal(t, exp.Received, act.Received)
 	assert.Equal(t, exp.Status, act.Status)
+	assert.Equal(t, exp.Confirms, act.Confirms)
 	assert.Equal(t, exp.Key, act.Key)
 	assert.Equal(t, exp.Timeout, act.Timeout)
 	assert.False(t, act.CreatedAt.IsZero())
@@ -0,0 +1,84 @@
+// Copyright (C) 2024 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package stripe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spacemonkeygo/monkit/v3"
+	"go.uber.org/zap"
+
+	"storj.io/common/sync2"
+)
+
+// UnappliedStat is a chore which periodically counts the received coinpayments
+// transactions with an unapplied balance intent and reports the count as a gauge.
+type UnappliedStat struct {
+	log          *zap.Logger
+	transactions TransactionsDB
+	Loop         *sync2.Cycle
+
+	mu      sync.Mutex
+	count   int64
+	updated time.Time
+}
+
+var _ monkit.StatSource = &UnappliedStat{}
+
+// NewUnappliedStat creates a chore to report the number of unapplied coinpayments transactions.
+func NewUnappliedStat(log *zap.Logger, registry *monkit.Registry, transactions TransactionsDB, interval time.Duration) *UnappliedStat {
+	chore := &UnappliedStat{
+		log:          log,
+		transactions: transactions,
+		Loop:         sync2.NewCycle(interval),
+	}
+	registry.Package().Chain(chore)
+	return chore
+}
+
+// Run runs the chore until the context is canceled.
+func (chore *UnappliedStat) Run(ctx context.Context) (err error) {
+	defer mon.Task()(&ctx)(&err)
+	return chore.Loop.Run(ctx, func(ctx context.Context) error {
+		chore.RunOnce(ctx)
+		return nil
+	})
+}
+
+// RunOnce refreshes the number of unapplied transactions.
+func (chore *UnappliedStat) RunOnce(ctx context.Context) {
+	now := time.Now()
+	count, err := chore.transactions.CountUnapplied(ctx, now)
+	if err != nil {
+		chore.log.Error("couldn't count unapplied coinpayments transactions", zap.Error(err))
+		return
+	}
+
+	chore.mu.Lock()
+	chore.count = count
+	chore.updated = now
+	chore.mu.Unlock()
+}
+
+// Close stops the chore.
+func (chore *UnappliedStat) Close() error {
+	chore.Loop.Close()
+	return nil
+}
+
+// Stats implements monkit.StatSource.
+func (chore *UnappliedStat) Stats(cb func(key monkit.SeriesKey, field string, val float64)) {
+	chore.mu.Lock()
+	defer chore.mu.Unlock()
+
+	if chore.updated.IsZero() {
+		return
+	}
+
+	key := monkit.NewSeriesKey("coinpayments_unapplied_intents")
+	cb(key, "count", float64(chore.count))
+	cb(key, "age", time.Since(chore.updated).Seconds())
+}
@@ -165,6 +165,10 @@ type Config struct {
 	Debug    debug.Config
 
 	Placement nodeselection.ConfigurablePlacementRule `help:"detailed placement rules in the form 'id:definition;id:definition;...' where id is a 16 bytes integer (use >10 for backward compatibility), definition is a combination of the following functions:country(2 letter country codes,...), tag(nodeId, key, bytes(value)) all(...,...)."`
+	// PlacementAllowOverride is applied to Placement, as it can't be set with the Placement flag itself.
+	PlacementAllowOverride bool `help:"allow the same placement id to be defined multiple times in the placement rules, the last definition wins" default:"false"`
+	// PlacementAllowEveryCountryOverride is applied to Placement, as it can't be set with the Placement flag itself.
+	PlacementAllowEveryCountryOverride bool `help:"allow redefining placement 0 (every country) in the placement rules, which is unrestricted by default" default:"false"`
 
 	Admin admin.Config
 
@@ -232,6 +236,14 @@ type Config struct {
 	TagAuthorities string `help:"comma-separated paths of additional cert files, used to validate signed node tags"`
 }
 
+// PlacementRules returns the configured placement rules.
+func (c *Config) PlacementRules() nodeselection.ConfigurablePlacementRule {
+	rules := c.Placement
+	rules.AllowOverride = c.PlacementAllowOverride
+	rules.AllowEveryCountryOverride = c.PlacementAllowEveryCountryOverride
+	return rules
+}
+
 func setupMailService(log *zap.Logger, config Config) (*mailservice.Service, error) {
 	fromAndHost := func(cfg mailservice.Config) (*mail.Address, string, error) {
 		// validate from mail address
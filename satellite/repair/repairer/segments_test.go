@@ -215,8 +215,10 @@ func TestSegmentRepairWithNodeTags(t *testing.T) {
 				func(log *zap.Logger, index int, config *satellite.Config) {
 					tag := fmt.Sprintf(`tag("%s","selected","true")`, satelliteIdentity.ID())
 					config.Placement = nodeselection.ConfigurablePlacementRule{
-						PlacementRules: fmt.Sprintf("0:exclude(%s);10:%s", tag, tag),
+						PlacementRules:            fmt.Sprintf("0:exclude(%s);10:%s", tag, tag),
+						AllowEveryCountryOverride: true,
 					}
+					config.PlacementAllowEveryCountryOverride = true
 				},
 				func(log *zap.Logger, index int, config *satellite.Config) {
 
@@ -5,18 +5,38 @@ package satellitedb
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/zeebo/errs"
+	"go.uber.org/zap"
 
 	"storj.io/common/currency"
 	"storj.io/common/uuid"
 	"storj.io/storj/satellite/payments/coinpayments"
 	"storj.io/storj/satellite/payments/stripe"
 	"storj.io/storj/satellite/satellitedb/dbx"
+	"storj.io/storj/shared/tagsql"
 )
 
+// applyBalanceIntentState defines states of the apply balance intents.
+type applyBalanceIntentState int
+
+const (
+	// applyBalanceIntentStateUnapplied defines state when transaction was not applied.
+	applyBalanceIntentStateUnapplied applyBalanceIntentState = 0
+	// applyBalanceIntentStateConsumed defines state when transaction was applied.
+	applyBalanceIntentStateConsumed applyBalanceIntentState = 1
+)
+
+// Int returns intent state as int.
+func (intent applyBalanceIntentState) Int() int {
+	return int(intent)
+}
+
 // ensure that coinpaymentsTransactions implements stripecoinpayments.TransactionsDB.
 var _ stripe.TransactionsDB = (*coinPaymentsTransactions)(nil)
 
@@ -27,22 +47,68 @@ type coinPaymentsTransactions struct {
 	db *satelliteDB
 }
 
-// GetLockedRate returns locked conversion rate for transaction or error if non exists.
+// GetLockedRate returns locked conversion rate for transaction or ErrRateNotLocked if non exists.
 func (db *coinPaymentsTransactions) GetLockedRate(ctx context.Context, id coinpayments.TransactionID) (rate decimal.Decimal, err error) {
 	defer mon.Task()(&ctx)(&err)
 
+	rate, _, err = db.GetLockedRateWithTime(ctx, id)
+	return rate, err
+}
+
+// GetLockedRateRounded returns locked conversion rate for transaction rounded to the given
+// decimal places or ErrRateNotLocked if non exists.
+func (db *coinPaymentsTransactions) GetLockedRateRounded(ctx context.Context, id coinpayments.TransactionID, places int32) (rate decimal.Decimal, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rate, _, err = db.GetLockedRateWithTime(ctx, id)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return rate.Round(places), nil
+}
+
+// GetLockedRateWithTime returns locked conversion rate for transaction and the time
+// it was locked at or ErrRateNotLocked if non exists.
+func (db *coinPaymentsTransactions) GetLockedRateWithTime(ctx context.Context, id coinpayments.TransactionID) (rate decimal.Decimal, lockedAt time.Time, err error) {
+	defer mon.Task()(&ctx)(&err)
+
 	dbxRate, err := db.db.Get_StripecoinpaymentsTxConversionRate_By_TxId(ctx,
 		dbx.StripecoinpaymentsTxConversionRate_TxId(id.String()),
 	)
 	if err != nil {
-		return decimal.Decimal{}, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Decimal{}, time.Time{}, stripe.ErrRateNotLocked
+		}
+		return decimal.Decimal{}, time.Time{}, Error.Wrap(err)
 	}
 
 	rate = decimal.NewFromFloat(dbxRate.RateNumeric)
-	return rate, nil
+	return rate, dbxRate.CreatedAt, nil
+}
+
+// Get returns transaction with the given id.
+func (db *coinPaymentsTransactions) Get(ctx context.Context, id coinpayments.TransactionID) (_ *stripe.Transaction, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	dbxCPTX, err := db.db.Get_CoinpaymentsTransaction_By_Id(ctx,
+		dbx.CoinpaymentsTransaction_Id(id.String()),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, stripe.ErrTransactionNotFound
+		}
+		return nil, Error.Wrap(err)
+	}
+
+	tx, err := fromDBXCoinpaymentsTransaction(dbxCPTX)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return &tx, nil
 }
 
-// ListAccount returns all transaction for specific user.
+// ListAccount returns all transaction for specific user. It loads the whole history of the user
+// into memory, use ListAccountPage for accounts with many transactions.
 func (db *coinPaymentsTransactions) ListAccount(ctx context.Context, userID uuid.UUID) (_ []stripe.Transaction, err error) {
 	defer mon.Task()(&ctx)(&err)
 
@@ -57,19 +123,517 @@ func (db *coinPaymentsTransactions) ListAccount(ctx context.Context, userID uuid
 	return txs, Error.Wrap(err)
 }
 
+// ListAccountPage returns a page of transactions for specific user, ordered by creation time descending.
+func (db *coinPaymentsTransactions) ListAccountPage(ctx context.Context, userID uuid.UUID, offset int64, limit int) (page stripe.TransactionsPage, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if limit <= 0 {
+		return stripe.TransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT `+coinpaymentsTransactionColumns+`
+		FROM coinpayments_transactions
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`), userID[:], limit+1, offset)
+	if err != nil {
+		return stripe.TransactionsPage{}, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	txs, err := scanCoinpaymentsTransactions(rows)
+	if err != nil {
+		return stripe.TransactionsPage{}, Error.Wrap(err)
+	}
+
+	if len(txs) == limit+1 {
+		page.Next = true
+		page.NextOffset = offset + int64(limit)
+
+		txs = txs[:len(txs)-1]
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// ListPartialPayments returns received transactions created before the given time, which received
+// less than the requested amount, ordered by creation time descending. Amounts are stored as base units
+// of the transaction currency, so they are compared in the database.
+func (db *coinPaymentsTransactions) ListPartialPayments(ctx context.Context, before time.Time, limit int) (page stripe.TransactionsPage, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if limit <= 0 {
+		return stripe.TransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT `+coinpaymentsTransactionColumns+`
+		FROM coinpayments_transactions
+		WHERE status = ? AND created_at <= ? AND received_numeric < amount_numeric
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`), coinpayments.StatusReceived.Int(), before, limit+1)
+	if err != nil {
+		return stripe.TransactionsPage{}, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	txs, err := scanCoinpaymentsTransactions(rows)
+	if err != nil {
+		return stripe.TransactionsPage{}, Error.Wrap(err)
+	}
+
+	if len(txs) == limit+1 {
+		page.Next = true
+		txs = txs[:len(txs)-1]
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// ExportAccount returns all transactions for specific user in the data export format.
+func (db *coinPaymentsTransactions) ExportAccount(ctx context.Context, userID uuid.UUID) (_ []stripe.TransactionExport, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	txs, err := db.ListAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exports := make([]stripe.TransactionExport, 0, len(txs))
+	for _, tx := range txs {
+		exports = append(exports, tx.Export())
+	}
+	return exports, nil
+}
+
+// ListAccountFiltered returns transactions for specific user which have one of the given
+// statuses and were created at or after the given time. Empty statuses matches all statuses.
+func (db *coinPaymentsTransactions) ListAccountFiltered(ctx context.Context, userID uuid.UUID, statuses []coinpayments.Status, after time.Time) (_ []stripe.Transaction, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	args := []interface{}{userID[:], after}
+
+	var statusCondition string
+	if len(statuses) > 0 {
+		for _, status := range statuses {
+			args = append(args, status.Int())
+		}
+		statusCondition = `AND status IN (?` + strings.Repeat(", ?", len(statuses)-1) + `)`
+	}
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT `+coinpaymentsTransactionColumns+`
+		FROM coinpayments_transactions
+		WHERE user_id = ? AND created_at >= ?
+		`+statusCondition+`
+		ORDER BY created_at DESC
+	`), args...)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	txs, err := scanCoinpaymentsTransactions(rows)
+	return txs, Error.Wrap(err)
+}
+
+// TotalReceived returns the total amount received in the given currency
+// by the user's transactions which have at least the received status.
+func (db *coinPaymentsTransactions) TotalReceived(ctx context.Context, userID uuid.UUID, curr *currency.Currency) (_ currency.Amount, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if curr == nil {
+		return currency.Amount{}, Error.New("currency is not specified")
+	}
+
+	// amounts are stored as base units of the transaction currency,
+	// so they can be summed directly in the database.
+	var total int64
+	err = db.db.QueryRowContext(ctx, db.db.Rebind(`
+		SELECT COALESCE(SUM(received_numeric), 0)
+		FROM coinpayments_transactions
+		WHERE user_id = ? AND currency = ? AND status >= ?
+	`), userID[:], curr.Symbol(), coinpayments.StatusReceived.Int()).Scan(&total)
+	if err != nil {
+		return currency.Amount{}, Error.Wrap(err)
+	}
+
+	return currency.AmountFromBaseUnits(total, curr), nil
+}
+
+// DeleteExpired deletes pending and timed out transactions which timed out before the given time.
+// Transactions which have an apply balance intent are kept.
+func (db *coinPaymentsTransactions) DeleteExpired(ctx context.Context, before time.Time) (deleted int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	result, err := db.db.ExecContext(ctx, db.db.Rebind(`
+		DELETE FROM coinpayments_transactions
+		WHERE
+			status IN (?, ?) AND
+			created_at + timeout * INTERVAL '1 second' < ? AND
+			NOT EXISTS (
+				SELECT 1 FROM stripecoinpayments_apply_balance_intents AS ints
+				WHERE ints.tx_id = coinpayments_transactions.id
+			)
+	`), coinpayments.StatusPending.Int(), coinpayments.StatusTimedOut.Int(), before)
+	if err != nil {
+		return 0, Error.Wrap(err)
+	}
+
+	deleted, err = result.RowsAffected()
+	return deleted, Error.Wrap(err)
+}
+
+// MarkTimedOut changes the status of the pending transactions, which timed out before the given time,
+// to timed out.
+func (db *coinPaymentsTransactions) MarkTimedOut(ctx context.Context, before time.Time) (updated int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	result, err := db.db.ExecContext(ctx, db.db.Rebind(`
+		UPDATE coinpayments_transactions
+		SET status = ?
+		WHERE
+			status = ? AND
+			created_at + timeout * INTERVAL '1 second' < ?
+	`), coinpayments.StatusTimedOut.Int(), coinpayments.StatusPending.Int(), before)
+	if err != nil {
+		return 0, Error.Wrap(err)
+	}
+
+	updated, err = result.RowsAffected()
+	return updated, Error.Wrap(err)
+}
+
+// Reassign moves the transaction to the given account. Transactions which balance
+// was already applied can't be reassigned.
+func (db *coinPaymentsTransactions) Reassign(ctx context.Context, id coinpayments.TransactionID, newAccountID uuid.UUID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	var oldAccountID uuid.UUID
+	err = db.db.WithTx(ctx, func(ctx context.Context, tx *dbx.Tx) error {
+		var consumed bool
+		err := tx.Tx.QueryRowContext(ctx, tx.Rebind(`
+			SELECT user_id, EXISTS (
+				SELECT 1 FROM stripecoinpayments_apply_balance_intents AS ints
+				WHERE ints.tx_id = coinpayments_transactions.id AND ints.state = ?
+			)
+			FROM coinpayments_transactions
+			WHERE id = ?
+		`), applyBalanceIntentStateConsumed.Int(), id.String()).Scan(&oldAccountID, &consumed)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return stripe.ErrTransactionNotFound
+			}
+			return err
+		}
+		if consumed {
+			return stripe.ErrTransactionConsumed
+		}
+
+		_, err = tx.Tx.ExecContext(ctx, tx.Rebind(`
+			UPDATE coinpayments_transactions SET user_id = ? WHERE id = ?
+		`), newAccountID[:], id.String())
+		return err
+	})
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	db.db.log.Info("coinpayments transaction reassigned",
+		zap.String("transaction ID", id.String()),
+		zap.Stringer("old account ID", oldAccountID),
+		zap.Stringer("new account ID", newAccountID))
+	return nil
+}
+
+// CountUnapplied returns the number of received transactions created before the given time
+// which have an unapplied balance intent.
+func (db *coinPaymentsTransactions) CountUnapplied(ctx context.Context, before time.Time) (count int64, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	err = db.db.QueryRowContext(ctx, db.db.Rebind(`
+		SELECT COUNT(*)
+		FROM coinpayments_transactions AS txs
+		INNER JOIN stripecoinpayments_apply_balance_intents AS ints
+		ON txs.id = ints.tx_id
+		WHERE txs.status >= ? AND txs.created_at <= ? AND ints.state = ?
+	`), coinpayments.StatusReceived.Int(), before, applyBalanceIntentStateUnapplied.Int()).Scan(&count)
+	if err != nil {
+		return 0, Error.Wrap(err)
+	}
+
+	return count, nil
+}
+
+// ListAccountsWithUnapplied returns the accounts with the most received transactions created before
+// the given time which have an unapplied balance intent, ordered by the count descending.
+func (db *coinPaymentsTransactions) ListAccountsWithUnapplied(ctx context.Context, before time.Time, limit int) (accounts []stripe.UnappliedAccount, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if limit <= 0 {
+		return nil, Error.New("limit must be positive, got %d", limit)
+	}
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT txs.user_id, COUNT(*) AS unapplied
+		FROM coinpayments_transactions AS txs
+		INNER JOIN stripecoinpayments_apply_balance_intents AS ints
+		ON txs.id = ints.tx_id
+		WHERE txs.status >= ? AND txs.created_at <= ? AND ints.state = ?
+		GROUP BY txs.user_id
+		ORDER BY unapplied DESC, txs.user_id
+		LIMIT ?
+	`), coinpayments.StatusReceived.Int(), before, applyBalanceIntentStateUnapplied.Int(), limit)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, Error.Wrap(rows.Close())) }()
+
+	for rows.Next() {
+		var account stripe.UnappliedAccount
+		if err := rows.Scan(&account.AccountID, &account.Count); err != nil {
+			return nil, Error.Wrap(err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, Error.Wrap(rows.Err())
+}
+
+// FindUnappliedMissingRate returns the ids of the received transactions created before the given time
+// which have an unapplied balance intent, but no locked conversion rate. Such transactions can't be applied.
+func (db *coinPaymentsTransactions) FindUnappliedMissingRate(ctx context.Context, before time.Time) (ids []coinpayments.TransactionID, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT txs.id
+		FROM coinpayments_transactions AS txs
+		INNER JOIN stripecoinpayments_apply_balance_intents AS ints
+		ON txs.id = ints.tx_id
+		LEFT JOIN stripecoinpayments_tx_conversion_rates AS rates
+		ON txs.id = rates.tx_id
+		WHERE txs.status >= ? AND txs.created_at <= ? AND ints.state = ? AND rates.tx_id IS NULL
+		ORDER BY txs.created_at, txs.id
+	`), coinpayments.StatusReceived.Int(), before, applyBalanceIntentStateUnapplied.Int())
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, Error.Wrap(rows.Close())) }()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, Error.Wrap(err)
+		}
+		ids = append(ids, coinpayments.TransactionID(id))
+	}
+	return ids, Error.Wrap(rows.Err())
+}
+
+// ApplyBalanceIntentSummary returns the number of unapplied and consumed apply balance intents
+// created before the given time, and the creation time of the oldest unapplied one.
+// oldestUnapplied is zero when there are no unapplied intents.
+func (db *coinPaymentsTransactions) ApplyBalanceIntentSummary(ctx context.Context, before time.Time) (unapplied, consumed int64, oldestUnapplied time.Time, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT state, COUNT(*), MIN(created_at)
+		FROM stripecoinpayments_apply_balance_intents
+		WHERE created_at <= ? AND state IN (?, ?)
+		GROUP BY state
+	`), before, applyBalanceIntentStateUnapplied.Int(), applyBalanceIntentStateConsumed.Int())
+	if err != nil {
+		return 0, 0, time.Time{}, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, Error.Wrap(rows.Close())) }()
+
+	for rows.Next() {
+		var state int
+		var count int64
+		var oldest time.Time
+		if err := rows.Scan(&state, &count, &oldest); err != nil {
+			return 0, 0, time.Time{}, Error.Wrap(err)
+		}
+
+		switch applyBalanceIntentState(state) {
+		case applyBalanceIntentStateUnapplied:
+			unapplied, oldestUnapplied = count, oldest
+		case applyBalanceIntentStateConsumed:
+			consumed = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, time.Time{}, Error.Wrap(err)
+	}
+
+	return unapplied, consumed, oldestUnapplied, nil
+}
+
+// ListUnappliedAfter returns a page of received transactions created before the given time
+// which have an unapplied balance intent. Transactions are ordered by creation time and id
+// descending and the page starts after the transaction identified by afterCreatedAt and afterID.
+// Zero afterCreatedAt starts from the beginning.
+func (db *coinPaymentsTransactions) ListUnappliedAfter(ctx context.Context, afterCreatedAt time.Time, afterID coinpayments.TransactionID, limit int, before time.Time) (page stripe.UnappliedTransactionsPage, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if limit <= 0 {
+		return stripe.UnappliedTransactionsPage{}, Error.New("limit must be positive, got %d", limit)
+	}
+
+	args := []interface{}{coinpayments.StatusReceived.Int(), before, applyBalanceIntentStateUnapplied.Int()}
+
+	var keysetCondition string
+	if !afterCreatedAt.IsZero() {
+		keysetCondition = `AND (created_at, id) < (?, ?)`
+		args = append(args, afterCreatedAt, afterID.String())
+	}
+	args = append(args, limit+1)
+
+	rows, err := db.db.QueryContext(ctx, db.db.Rebind(`
+		SELECT `+coinpaymentsTransactionColumns+`
+		FROM coinpayments_transactions
+		WHERE
+			status >= ? AND created_at <= ? AND
+			EXISTS (
+				SELECT 1 FROM stripecoinpayments_apply_balance_intents AS ints
+				WHERE ints.tx_id = coinpayments_transactions.id AND ints.state = ?
+			)
+			`+keysetCondition+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`), args...)
+	if err != nil {
+		return stripe.UnappliedTransactionsPage{}, Error.Wrap(err)
+	}
+	defer func() { err = errs.Combine(err, rows.Close()) }()
+
+	txs, err := scanCoinpaymentsTransactions(rows)
+	if err != nil {
+		return stripe.UnappliedTransactionsPage{}, Error.Wrap(err)
+	}
+
+	if len(txs) == limit+1 {
+		txs = txs[:len(txs)-1]
+		page.Next = true
+	}
+	if len(txs) > 0 {
+		last := txs[len(txs)-1]
+		page.LastCreatedAt = last.CreatedAt
+		page.LastID = last.ID
+	}
+
+	page.Transactions = txs
+	return page, nil
+}
+
+// RescaleAmounts changes the currency of the given transactions from one currency to another and
+// rescales the amounts to the decimal places of the new currency. All the transactions must be in the
+// from currency, so already rescaled transactions are rejected and none of the transactions is changed.
+func (db *coinPaymentsTransactions) RescaleAmounts(ctx context.Context, from, to *currency.Currency, ids []coinpayments.TransactionID) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	if from == nil || to == nil {
+		return Error.New("currency is not specified")
+	}
+	if from.Symbol() == to.Symbol() {
+		return Error.New("transactions are already in %s", to.Symbol())
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []interface{}{from.Symbol()}
+	for _, id := range ids {
+		args = append(args, id.String())
+	}
+
+	err = db.db.WithTx(ctx, func(ctx context.Context, tx *dbx.Tx) (err error) {
+		rows, err := tx.Tx.QueryContext(ctx, tx.Rebind(`
+			SELECT id, amount_numeric, received_numeric
+			FROM coinpayments_transactions
+			WHERE currency = ? AND id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		`), args...)
+		if err != nil {
+			return err
+		}
+		defer func() { err = errs.Combine(err, rows.Close()) }()
+
+		type rescaled struct {
+			id               string
+			amount, received currency.Amount
+		}
+		var txs []rescaled
+		for rows.Next() {
+			var id string
+			var amount, received int64
+			if err := rows.Scan(&id, &amount, &received); err != nil {
+				return err
+			}
+
+			rescaledTx := rescaled{id: id}
+			if rescaledTx.amount, err = stripe.RescaleAmount(currency.AmountFromBaseUnits(amount, from), to); err != nil {
+				return err
+			}
+			if rescaledTx.received, err = stripe.RescaleAmount(currency.AmountFromBaseUnits(received, from), to); err != nil {
+				return err
+			}
+			txs = append(txs, rescaledTx)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(txs) != len(ids) {
+			return Error.New("%d of %d transactions don't exist or aren't in %s", len(ids)-len(txs), len(ids), from.Symbol())
+		}
+
+		for _, rescaledTx := range txs {
+			_, err := tx.Tx.ExecContext(ctx, tx.Rebind(`
+				UPDATE coinpayments_transactions
+				SET amount_numeric = ?, received_numeric = ?, currency = ?
+				WHERE id = ? AND currency = ?
+			`), rescaledTx.amount.BaseUnits(), rescaledTx.received.BaseUnits(), to.Symbol(), rescaledTx.id, from.Symbol())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Error.Wrap(err)
+	}
+
+	db.db.log.Info("coinpayments transactions rescaled",
+		zap.String("from", from.Symbol()),
+		zap.String("to", to.Symbol()),
+		zap.Int("count", len(ids)))
+	return nil
+}
+
 // TestInsert inserts new coinpayments transaction into DB.
 func (db *coinPaymentsTransactions) TestInsert(ctx context.Context, tx stripe.Transaction) (createTime time.Time, err error) {
 	defer mon.Task()(&ctx)(&err)
 
+	timeout, err := timeoutSeconds(tx.Timeout)
+	if err != nil {
+		return time.Time{}, err
+	}
+
 	dbxCPTX, err := db.db.Create_CoinpaymentsTransaction(ctx,
 		dbx.CoinpaymentsTransaction_Id(tx.ID.String()),
 		dbx.CoinpaymentsTransaction_UserId(tx.AccountID[:]),
 		dbx.CoinpaymentsTransaction_Address(tx.Address),
 		dbx.CoinpaymentsTransaction_AmountNumeric(tx.Amount.BaseUnits()),
 		dbx.CoinpaymentsTransaction_ReceivedNumeric(tx.Received.BaseUnits()),
+		dbx.CoinpaymentsTransaction_Currency(tx.Amount.Currency().Symbol()),
 		dbx.CoinpaymentsTransaction_Status(tx.Status.Int()),
+		dbx.CoinpaymentsTransaction_Confirms(tx.Confirms),
 		dbx.CoinpaymentsTransaction_Key(tx.Key),
-		dbx.CoinpaymentsTransaction_Timeout(int(tx.Timeout.Seconds())),
+		dbx.CoinpaymentsTransaction_Timeout(timeout),
 	)
 	if err != nil {
 		return time.Time{}, err
@@ -77,10 +641,101 @@ func (db *coinPaymentsTransactions) TestInsert(ctx context.Context, tx stripe.Tr
 	return dbxCPTX.CreatedAt, nil
 }
 
+// InsertWithRate inserts new coinpayments transaction and locks its conversion rate.
+// Either both of them are stored or none of them.
+func (db *coinPaymentsTransactions) InsertWithRate(ctx context.Context, tx stripe.Transaction, rate decimal.Decimal) (_ *stripe.Transaction, err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	timeout, err := timeoutSeconds(tx.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	rateFloat := conversionRateToFloat(rate)
+
+	var dbxCPTX *dbx.CoinpaymentsTransaction
+	err = db.db.WithTx(ctx, func(ctx context.Context, dbxTx *dbx.Tx) (err error) {
+		dbxCPTX, err = dbxTx.Create_CoinpaymentsTransaction(ctx,
+			dbx.CoinpaymentsTransaction_Id(tx.ID.String()),
+			dbx.CoinpaymentsTransaction_UserId(tx.AccountID[:]),
+			dbx.CoinpaymentsTransaction_Address(tx.Address),
+			dbx.CoinpaymentsTransaction_AmountNumeric(tx.Amount.BaseUnits()),
+			dbx.CoinpaymentsTransaction_ReceivedNumeric(tx.Received.BaseUnits()),
+			dbx.CoinpaymentsTransaction_Currency(tx.Amount.Currency().Symbol()),
+			dbx.CoinpaymentsTransaction_Status(tx.Status.Int()),
+			dbx.CoinpaymentsTransaction_Confirms(tx.Confirms),
+			dbx.CoinpaymentsTransaction_Key(tx.Key),
+			dbx.CoinpaymentsTransaction_Timeout(timeout),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = dbxTx.Create_StripecoinpaymentsTxConversionRate(ctx,
+			dbx.StripecoinpaymentsTxConversionRate_TxId(tx.ID.String()),
+			dbx.StripecoinpaymentsTxConversionRate_RateNumeric(rateFloat),
+		)
+		return err
+	})
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+
+	inserted, err := fromDBXCoinpaymentsTransaction(dbxCPTX)
+	if err != nil {
+		return nil, Error.Wrap(err)
+	}
+	return &inserted, nil
+}
+
+// LockRates locks conversion rates for multiple transactions at once.
+// Either all of the rates are locked or none of them.
+func (db *coinPaymentsTransactions) LockRates(ctx context.Context, rates map[coinpayments.TransactionID]decimal.Decimal) (err error) {
+	defer mon.Task()(&ctx)(&err)
+
+	rateFloats := make(map[coinpayments.TransactionID]float64, len(rates))
+	for id, rate := range rates {
+		rateFloats[id] = conversionRateToFloat(rate)
+	}
+
+	err = db.db.WithTx(ctx, func(ctx context.Context, tx *dbx.Tx) error {
+		for id, rateFloat := range rateFloats {
+			_, err := tx.Create_StripecoinpaymentsTxConversionRate(ctx,
+				dbx.StripecoinpaymentsTxConversionRate_TxId(id.String()),
+				dbx.StripecoinpaymentsTxConversionRate_RateNumeric(rateFloat),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return Error.Wrap(err)
+}
+
 // TestLockRate locks conversion rate for transaction.
 func (db *coinPaymentsTransactions) TestLockRate(ctx context.Context, id coinpayments.TransactionID, rate decimal.Decimal) (err error) {
 	defer mon.Task()(&ctx)(&err)
 
+	rateFloat := conversionRateToFloat(rate)
+
+	_, err = db.db.Create_StripecoinpaymentsTxConversionRate(ctx,
+		dbx.StripecoinpaymentsTxConversionRate_TxId(id.String()),
+		dbx.StripecoinpaymentsTxConversionRate_RateNumeric(rateFloat),
+	)
+	return Error.Wrap(err)
+}
+
+// timeoutSeconds converts the transaction timeout to the number of seconds stored in the DB.
+// Timeouts with a fraction of a second are rejected instead of being silently truncated.
+func timeoutSeconds(timeout time.Duration) (int, error) {
+	if timeout%time.Second != 0 {
+		return 0, Error.New("timeout should be a whole number of seconds, got %v", timeout)
+	}
+	return int(timeout / time.Second), nil
+}
+
+// conversionRateToFloat converts the conversion rate to the representation stored in the DB.
+func conversionRateToFloat(rate decimal.Decimal) float64 {
 	rateFloat, exact := rate.Float64()
 	if !exact {
 		// It's not clear at the time of writing whether this
@@ -110,11 +765,33 @@ func (db *coinPaymentsTransactions) TestLockRate(ctx context.Context, id coinpay
 		mon.FloatVal("inexact-float64-exchange-rate-delta").Observe(delta)
 	}
 
-	_, err = db.db.Create_StripecoinpaymentsTxConversionRate(ctx,
-		dbx.StripecoinpaymentsTxConversionRate_TxId(id.String()),
-		dbx.StripecoinpaymentsTxConversionRate_RateNumeric(rateFloat),
-	)
-	return Error.Wrap(err)
+	return rateFloat
+}
+
+// coinpaymentsTransactionColumns lists the columns read by scanCoinpaymentsTransactions.
+const coinpaymentsTransactionColumns = `id, user_id, address, amount_numeric, received_numeric, currency, status, confirms, key, timeout, created_at`
+
+// scanCoinpaymentsTransactions converts rows selected with coinpaymentsTransactionColumns
+// into stripecoinpayments.Transaction.
+func scanCoinpaymentsTransactions(rows tagsql.Rows) (txs []stripe.Transaction, err error) {
+	for rows.Next() {
+		var dbxCPTX dbx.CoinpaymentsTransaction
+		err := rows.Scan(
+			&dbxCPTX.Id, &dbxCPTX.UserId, &dbxCPTX.Address,
+			&dbxCPTX.AmountNumeric, &dbxCPTX.ReceivedNumeric, &dbxCPTX.Currency,
+			&dbxCPTX.Status, &dbxCPTX.Confirms, &dbxCPTX.Key, &dbxCPTX.Timeout, &dbxCPTX.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := fromDBXCoinpaymentsTransaction(&dbxCPTX)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
 }
 
 // fromDBXCoinpaymentsTransaction converts *dbx.CoinpaymentsTransaction to stripecoinpayments.Transaction.
@@ -124,16 +801,19 @@ func fromDBXCoinpaymentsTransaction(dbxCPTX *dbx.CoinpaymentsTransaction) (strip
 		return stripe.Transaction{}, errs.Wrap(err)
 	}
 
-	// TODO: the currency here should be passed in to this function or stored
-	//  in the database.
+	txCurrency, err := currency.FromSymbol(dbxCPTX.Currency)
+	if err != nil {
+		return stripe.Transaction{}, errs.Wrap(err)
+	}
 
 	return stripe.Transaction{
 		ID:        coinpayments.TransactionID(dbxCPTX.Id),
 		AccountID: userID,
 		Address:   dbxCPTX.Address,
-		Amount:    currency.AmountFromBaseUnits(dbxCPTX.AmountNumeric, currency.StorjToken),
-		Received:  currency.AmountFromBaseUnits(dbxCPTX.ReceivedNumeric, currency.StorjToken),
+		Amount:    currency.AmountFromBaseUnits(dbxCPTX.AmountNumeric, txCurrency),
+		Received:  currency.AmountFromBaseUnits(dbxCPTX.ReceivedNumeric, txCurrency),
 		Status:    coinpayments.Status(dbxCPTX.Status),
+		Confirms:  dbxCPTX.Confirms,
 		Key:       dbxCPTX.Key,
 		Timeout:   time.Second * time.Duration(dbxCPTX.Timeout),
 		CreatedAt: dbxCPTX.CreatedAt,
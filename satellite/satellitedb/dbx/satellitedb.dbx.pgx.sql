@@ -88,7 +88,9 @@ CREATE TABLE coinpayments_transactions (
 	address text NOT NULL,
 	amount_numeric bigint NOT NULL,
 	received_numeric bigint NOT NULL,
+	currency text NOT NULL,
 	status integer NOT NULL,
+	confirms integer NOT NULL,
 	key text NOT NULL,
 	timeout integer NOT NULL,
 	created_at timestamp with time zone NOT NULL,
@@ -2735,6 +2735,24 @@ func (db *satelliteDB) ProductionMigration() *migrate.Migration {
 					`ALTER TABLE account_freeze_events ADD COLUMN notifications_count integer NOT NULL DEFAULT 0;`,
 				},
 			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add currency column to coinpayments_transactions",
+				Version:     272,
+				Action: migrate.SQL{
+					`ALTER TABLE coinpayments_transactions ADD COLUMN currency text NOT NULL DEFAULT 'STORJ';`,
+					`ALTER TABLE coinpayments_transactions ALTER COLUMN currency DROP DEFAULT;`,
+				},
+			},
+			{
+				DB:          &db.migrationDB,
+				Description: "add confirms column to coinpayments_transactions",
+				Version:     273,
+				Action: migrate.SQL{
+					`ALTER TABLE coinpayments_transactions ADD COLUMN confirms integer NOT NULL DEFAULT 0;`,
+					`ALTER TABLE coinpayments_transactions ALTER COLUMN confirms DROP DEFAULT;`,
+				},
+			},
 			// NB: after updating testdata in `testdata`, run
 			//     `go generate` to update `migratez.go`.
 		},
@@ -13,7 +13,7 @@ func (db *satelliteDB) testMigration() *migrate.Migration {
 			{
 				DB:          &db.migrationDB,
 				Description: "Testing setup",
-				Version:     271,
+				Version:     273,
 				Action: migrate.SQL{`-- AUTOGENERATED BY storj.io/dbx
 -- DO NOT EDIT
 CREATE TABLE account_freeze_events (
@@ -104,7 +104,9 @@ CREATE TABLE coinpayments_transactions (
 	address text NOT NULL,
 	amount_numeric bigint NOT NULL,
 	received_numeric bigint NOT NULL,
+	currency text NOT NULL,
 	status integer NOT NULL,
+	confirms integer NOT NULL,
 	key text NOT NULL,
 	timeout integer NOT NULL,
 	created_at timestamp with time zone NOT NULL,
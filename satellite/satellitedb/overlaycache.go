@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blang/semver"
 	"github.com/spacemonkeygo/monkit/v3"
 	"github.com/zeebo/errs"
 	"go.uber.org/zap"
@@ -64,7 +65,8 @@ func (cache *overlaycache) selectAllStorageNodesUpload(ctx context.Context, sele
 	defer mon.Task()(&ctx)(&err)
 
 	query := `
-		SELECT id, address, email, wallet, last_net, last_ip_port, vetted_at, country_code, noise_proto, noise_public_key, debounce_limit, features, country_code
+		SELECT id, address, email, wallet, last_net, last_ip_port, vetted_at, country_code, noise_proto, noise_public_key, debounce_limit, features, country_code,
+			major, minor, patch, last_contact_success, free_disk
 			FROM nodes
 			` + cache.db.impl.AsOfSystemInterval(selectionCfg.AsOfSystemTime.Interval()) + `
 			WHERE disqualified IS NULL
@@ -106,11 +108,14 @@ func (cache *overlaycache) selectAllStorageNodesUpload(ctx context.Context, sele
 		var lastIPPort, email, wallet sql.NullString
 		var vettedAt *time.Time
 		var noise noiseScanner
+		var major, minor, patch int64
 		err = rows.Scan(&node.ID, &node.Address.Address, &email, &wallet, &node.LastNet, &lastIPPort, &vettedAt, &node.CountryCode, &noise.Proto,
-			&noise.PublicKey, &node.Address.DebounceLimit, &node.Address.Features, &node.CountryCode)
+			&noise.PublicKey, &node.Address.DebounceLimit, &node.Address.Features, &node.CountryCode,
+			&major, &minor, &patch, &node.LastContactSuccess, &node.FreeDisk)
 		if err != nil {
 			return nil, nil, err
 		}
+		node.Version = nodeVersion(major, minor, patch)
 		if lastIPPort.Valid {
 			node.LastIPPort = lastIPPort.String
 		}
@@ -159,7 +164,8 @@ func (cache *overlaycache) selectAllStorageNodesDownload(ctx context.Context, on
 
 	query := `
 		SELECT id, address, email, wallet, last_net, last_ip_port, noise_proto, noise_public_key, debounce_limit, features, country_code,
-               exit_initiated_at IS NOT NULL AS exiting, (unknown_audit_suspended IS NOT NULL OR offline_suspended IS NOT NULL) AS suspended, vetted_at is not null as vetted
+               exit_initiated_at IS NOT NULL AS exiting, (unknown_audit_suspended IS NOT NULL OR offline_suspended IS NOT NULL) AS suspended, vetted_at is not null as vetted,
+               major, minor, patch, last_contact_success, free_disk
 			FROM nodes
 			` + cache.db.impl.AsOfSystemInterval(asOfConfig.Interval()) + `
 			WHERE disqualified IS NULL
@@ -183,12 +189,14 @@ func (cache *overlaycache) selectAllStorageNodesDownload(ctx context.Context, on
 		node.Address = &pb.NodeAddress{}
 		var lastIPPort, email, wallet sql.NullString
 		var noise noiseScanner
+		var major, minor, patch int64
 		var err = rows.Scan(&node.ID, &node.Address.Address, &node.Email, &node.Wallet, &node.LastNet, &lastIPPort, &noise.Proto,
 			&noise.PublicKey, &node.Address.DebounceLimit, &node.Address.Features, &node.CountryCode,
-			&node.Exiting, &node.Suspended, &node.Vetted)
+			&node.Exiting, &node.Suspended, &node.Vetted, &major, &minor, &patch, &node.LastContactSuccess, &node.FreeDisk)
 		if err != nil {
 			return nil, err
 		}
+		node.Version = nodeVersion(major, minor, patch)
 		if lastIPPort.Valid {
 			node.LastIPPort = lastIPPort.String
 		}
@@ -417,7 +425,8 @@ func (cache *overlaycache) GetNodes(ctx context.Context, nodeIDs storj.NodeIDLis
 			n.exit_initiated_at IS NOT NULL AS exiting,
 			n.exit_finished_at IS NOT NULL AS exited,
             node_tags.name, node_tags.value, node_tags.signed_at, node_tags.signer,
-            n.vetted_at IS NOT NULL AS vetted
+            n.vetted_at IS NOT NULL AS vetted,
+			n.major, n.minor, n.patch, n.last_contact_success, n.free_disk
 		FROM unnest($1::bytea[]) WITH ORDINALITY AS input(node_id, ordinal)
 			LEFT OUTER JOIN nodes n ON input.node_id = n.id
             LEFT JOIN node_tags on node_tags.node_id = n.id
@@ -473,7 +482,8 @@ func (cache *overlaycache) GetParticipatingNodes(ctx context.Context, onlineWind
 			false AS disqualified,
 			exit_initiated_at IS NOT NULL AS exiting,
 			false AS exited,
-			vetted_at IS NOT NULL AS vetted
+			vetted_at IS NOT NULL AS vetted,
+			major, minor, patch, last_contact_success, free_disk
 		FROM nodes
 			`+cache.db.impl.AsOfSystemInterval(asOfSystemInterval)+`
 		WHERE disqualified IS NULL
@@ -527,14 +537,28 @@ func (n *nullNodeID) Scan(value any) error {
 	return nil
 }
 
+// nodeVersion converts the version columns of the nodes table to the version of the node.
+func nodeVersion(major, minor, patch int64) version.SemVer {
+	return version.SemVer{
+		Version: semver.Version{
+			Major: uint64(major),
+			Minor: uint64(minor),
+			Patch: uint64(patch),
+		},
+	}
+}
+
 func scanSelectedNode(rows tagsql.Rows) (nodeselection.SelectedNode, error) {
 	var node nodeselection.SelectedNode
 	node.Address = &pb.NodeAddress{}
 	var nodeID nullNodeID
 	var address, email, wallet, lastNet, lastIPPort, countryCode sql.NullString
 	var online, suspended, disqualified, exiting, exited, vetted sql.NullBool
+	var major, minor, patch sql.NullInt64
+	var lastContactSuccess sql.NullTime
+	var freeDisk sql.NullInt64
 	err := rows.Scan(&nodeID, &address, &email, &wallet, &lastNet, &lastIPPort, &countryCode,
-		&online, &suspended, &disqualified, &exiting, &exited, &vetted)
+		&online, &suspended, &disqualified, &exiting, &exited, &vetted, &major, &minor, &patch, &lastContactSuccess, &freeDisk)
 	if err != nil {
 		return nodeselection.SelectedNode{}, err
 	}
@@ -564,6 +588,9 @@ func scanSelectedNode(rows tagsql.Rows) (nodeselection.SelectedNode, error) {
 	node.Suspended = suspended.Bool
 	node.Exiting = exiting.Bool
 	node.Vetted = vetted.Bool
+	node.Version = nodeVersion(major.Int64, minor.Int64, patch.Int64)
+	node.LastContactSuccess = lastContactSuccess.Time
+	node.FreeDisk = freeDisk.Int64
 	return node, nil
 }
 
@@ -573,6 +600,9 @@ func scanSelectedNodeWithTag(rows tagsql.Rows) (_ nodeselection.SelectedNode, _
 	var nodeID nullNodeID
 	var address, wallet, email, lastNet, lastIPPort, countryCode sql.NullString
 	var online, suspended, disqualified, exiting, exited, vetted sql.NullBool
+	var major, minor, patch sql.NullInt64
+	var lastContactSuccess sql.NullTime
+	var freeDisk sql.NullInt64
 
 	var tag nodeselection.NodeTag
 	var name []byte
@@ -580,7 +610,8 @@ func scanSelectedNodeWithTag(rows tagsql.Rows) (_ nodeselection.SelectedNode, _
 	signer := nullNodeID{}
 
 	err = rows.Scan(&nodeID, &address, &email, &wallet, &lastNet, &lastIPPort, &countryCode,
-		&online, &suspended, &disqualified, &exiting, &exited, &name, &tag.Value, &signedAt, &signer, &vetted)
+		&online, &suspended, &disqualified, &exiting, &exited, &name, &tag.Value, &signedAt, &signer, &vetted,
+		&major, &minor, &patch, &lastContactSuccess, &freeDisk)
 	if err != nil {
 		return nodeselection.SelectedNode{}, nodeselection.NodeTag{}, true, err
 	}
@@ -607,6 +638,9 @@ func scanSelectedNodeWithTag(rows tagsql.Rows) (_ nodeselection.SelectedNode, _
 	node.Suspended = suspended.Bool
 	node.Exiting = exiting.Bool
 	node.Vetted = vetted.Bool
+	node.Version = nodeVersion(major.Int64, minor.Int64, patch.Int64)
+	node.LastContactSuccess = lastContactSuccess.Time
+	node.FreeDisk = freeDisk.Int64
 
 	if len(name) > 0 {
 		tag.Name = string(name)
@@ -386,9 +386,10 @@ func TestOverlayCache_SelectAllStorageNodesDownloadUpload(t *testing.T) {
 				Address:     &pb.NodeAddress{Address: ip.String()},
 				LastNet:     lastNet,
 				LastIPPort:  "0.0.0.0:0",
-				Version:     &pb.NodeVersion{Version: "v0.0.0"},
+				Version:     &pb.NodeVersion{Version: fmt.Sprintf("v1.%d.0", n)},
 				NodeID:      id,
 				CountryCode: location.Canada,
+				Capacity:    &pb.NodeCapacity{FreeDisk: int64(1000 + n)},
 			}
 			err := cache.UpdateCheckIn(ctx, infos[n], time.Now().UTC(), overlay.NodeSelectionConfig{})
 			require.NoError(t, err)
@@ -425,6 +426,9 @@ func TestOverlayCache_SelectAllStorageNodesDownloadUpload(t *testing.T) {
 				require.Equal(t, info.CountryCode, selectedNode.CountryCode)
 				require.Equal(t, info.LastIPPort, selectedNode.LastIPPort)
 				require.Equal(t, info.LastNet, selectedNode.LastNet)
+				require.Equal(t, info.Version.Version, selectedNode.Version.String())
+				require.WithinDuration(t, time.Now(), selectedNode.LastContactSuccess, time.Minute)
+				require.Equal(t, info.Capacity.FreeDisk, selectedNode.FreeDisk)
 				segments := strings.Split(selectedNode.Address.Address, ".")
 				origIndex, err := strconv.Atoi(segments[len(segments)-1])
 				require.NoError(t, err)
@@ -556,6 +560,9 @@ func TestOverlayCache_GetNodes(t *testing.T) {
 					assert.Zero(t, n, testNum, i)
 				} else {
 					assert.Equal(t, tc.QueryNodes[i].id, selectedNodes[i].ID, "%d:%d", testNum, i)
+					// the exact last contact time is checked separately.
+					assert.False(t, n.LastContactSuccess.IsZero(), "%d:%d", testNum, i)
+					n.LastContactSuccess = time.Time{}
 					if n.Online {
 						gotOnline = append(gotOnline, n)
 					} else {
@@ -580,6 +587,8 @@ func TestOverlayCache_GetNodes(t *testing.T) {
 		selection, err := cache.GetNodes(ctx, allIDs, 1*time.Hour, -1*time.Microsecond)
 		require.NoError(t, err)
 
+		require.WithinDuration(t, time.Now().Add(-allNodes[0].offlineInterval), selection[0].LastContactSuccess, time.Minute)
+
 		require.Equal(t, "0x9b7488BF8b6A4FF21D610e3dd202723f705cD1C0", selection[0].Wallet)
 		require.Equal(t, "test@storj.io", selection[0].Email)
 		require.True(t, selection[0].Vetted)
@@ -634,6 +643,10 @@ func TestOverlayCache_GetParticipatingNodes(t *testing.T) {
 			}
 			gotNodes, err := cache.GetParticipatingNodes(ctx, tc.OnlineWindow, 0)
 			require.NoError(t, err)
+			for j := range gotNodes {
+				require.False(t, gotNodes[j].LastContactSuccess.IsZero())
+				gotNodes[j].LastContactSuccess = time.Time{}
+			}
 			require.ElementsMatch(t, expectedNodes, gotNodes, "#%d", i)
 		}
 